@@ -2,9 +2,14 @@ package actions
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/maxtoolbox/maxlog/internal/cmdln"
+	"github.com/maxtoolbox/maxlog/internal/record"
+	"github.com/maxtoolbox/maxlog/internal/source"
 )
 
 // ActionFunc defines a function type that operates on an Action instance.
@@ -19,6 +24,28 @@ type Action struct {
 	tail      string     // The tail parameter for the action.
 	follow    bool       // The follow parameter for the action.
 	focus     string     // The focus parameter for the action.
+	exclude   string     // The exclude parameter hiding matching lines.
+	file      string     // The log files for the file mode.
+	stdin     bool       // Whether to read the logs from the standard input.
+	watch     bool       // Whether to attach to pods joining while following.
+	prefix    string     // The kind of source prefix of every line.
+	merge     bool       // Whether to order the lines of all streams by timestamp.
+	window    string     // The reorder window of the merge.
+	previous  bool       // Whether to read the logs of the previous container instance.
+	container string     // The containers inside the pods.
+	initCont  bool       // Whether to include the init containers of the pods.
+	profile   string     // The selector profile of the MAS application.
+	instance  string     // The MAS instance ID.
+	selector  string     // The raw label selector of the pods.
+	fields    string     // The field selector of the pods.
+	pod       string     // The filter on the pod names.
+	context   string     // The kubeconfig contexts.
+	config    string     // The path of the kubeconfig file.
+	events    bool       // Whether to interleave the Kubernetes events of the pods.
+	output    string     // The output format of the inspect action, text or json.
+	label     string     // The label filters of the Podman containers.
+	since     string     // The start of the time window.
+	until     string     // The end of the time window.
 	runAction ActionFunc // The function to execute the action.
 }
 
@@ -86,13 +113,15 @@ func trimSubCmd(subcmd string) string {
 // Returns:
 //
 //	[]string - A slice of strings containing subcommands and their values.
+//
+// Behavior:
+//   - Splits at the first "=" only, so values like selector=app=myapp or label=com.docker.compose.project=mas are kept whole.
 func splitSubCmd(args []string) []string {
 	subCmds := []string{}
 	for _, arg := range args {
-		if strings.Contains(arg, "=") {
-			vals := strings.Split(arg, "=")
-			subCmds = append(subCmds, vals[0])
-			subCmds = append(subCmds, vals[1])
+		if name, value, found := strings.Cut(arg, "="); found {
+			subCmds = append(subCmds, name)
+			subCmds = append(subCmds, value)
 		} else {
 			subCmds = append(subCmds, arg)
 		}
@@ -100,6 +129,18 @@ func splitSubCmd(args []string) []string {
 	return subCmds
 }
 
+// parseFlag interprets the value of a boolean parameter.
+// Parameters:
+//
+//	value - The value of the parameter.
+//
+// Returns:
+//
+//	bool - false for "0", "no" and "false", otherwise true.
+func parseFlag(value string) bool {
+	return !(value == "0" || value == "no" || value == "false")
+}
+
 // Init initializes the Action with the provided arguments.
 // Parameters:
 //
@@ -111,7 +152,18 @@ func splitSubCmd(args []string) []string {
 func (act *Action) Init(args []string) error {
 	act.follow = true
 	act.tag = ""
-	args = splitSubCmd(args)
+	act.watch, _ = strconv.ParseBool(cmdln.GetEnv("MAXLOG_WATCH", strconv.FormatBool(os.Getenv("MAXLOG_MODE") == "pod")))
+	act.merge, _ = strconv.ParseBool(cmdln.GetEnv("MAXLOG_MERGE", "false"))
+	act.events, _ = strconv.ParseBool(cmdln.GetEnv("MAXLOG_K8S_EVENTS", "false"))
+	params := []string{}
+	for _, arg := range args {
+		if arg == "-" {
+			act.stdin = true
+		} else {
+			params = append(params, arg)
+		}
+	}
+	args = splitSubCmd(params)
 	if len(args)%2 > 0 {
 		return fmt.Errorf("Missing second parameter")
 	}
@@ -126,12 +178,54 @@ func (act *Action) Init(args []string) error {
 		case "tail":
 			act.tail = args[i+1]
 		case "follow":
-			act.follow = !(args[i+1] == "0" || args[i+1] == "no" || args[i+1] == "false")
+			act.follow = parseFlag(args[i+1])
+		case "watch":
+			act.watch = parseFlag(args[i+1])
+		case "prefix":
+			act.prefix = args[i+1]
+		case "merge":
+			act.merge = parseFlag(args[i+1])
+		case "window":
+			act.window = args[i+1]
+		case "previous":
+			act.previous = parseFlag(args[i+1])
+		case "container":
+			act.container = args[i+1]
+		case "initContainers":
+			act.initCont = parseFlag(args[i+1])
+		case "profile":
+			act.profile = args[i+1]
+		case "instance":
+			act.instance = args[i+1]
+		case "selector":
+			act.selector = args[i+1]
+		case "fieldSelector":
+			act.fields = args[i+1]
+		case "pod":
+			act.pod = args[i+1]
+		case "context":
+			act.context = args[i+1]
+		case "kubeconfig":
+			act.config = args[i+1]
+		case "events":
+			act.events = parseFlag(args[i+1])
+		case "output":
+			act.output = args[i+1]
+		case "label":
+			act.label = args[i+1]
+		case "since":
+			act.since = args[i+1]
+		case "until":
+			act.until = args[i+1]
 		case "focus":
 			act.focus = args[i+1]
 			if act.focus != "" {
 				cmdln.Focus = act.focus
 			}
+		case "exclude":
+			act.exclude = args[i+1]
+		case "file":
+			act.file = args[i+1]
 		}
 	}
 	return nil
@@ -143,3 +237,149 @@ func (act *Action) Init(args []string) error {
 func (act *Action) Run() {
 	act.runAction(act)
 }
+
+// options collects the parameters of the Action into source options.
+//
+// Returns:
+//
+//	source.Options - The options passed to the LogSource.
+//
+// Behavior:
+//   - Uses MAXLOG_TAIL (default: 40) if no tail parameter is set.
+//   - Uses MAXLOG_PREFIX if no prefix parameter is set. Without both, the source decides, which is no prefix for most sources.
+//   - Uses MAXLOG_MERGE_WINDOW (default: 1s) if no window parameter is set.
+//   - Logs a fatal error if the window is not a valid duration.
+//   - Disables follow for the previous container instance, as its log has ended.
+//   - Uses MAXLOG_SINCE and MAXLOG_UNTIL if no since or until parameter is set, and disables follow if the time window has already ended.
+//     A clock time given as until refers to its first occurrence after since, or to today without since.
+//   - Logs a fatal error if since or until is not a valid time or until is before since.
+//   - Compiles the focus and exclude expressions, using MAXLOG_EXCLUDE if no exclude parameter is set.
+//     Logs a fatal error if a regular expression is invalid.
+//   - Merges the streams if the events are shown, so they are in timestamp order with the log lines.
+func (act *Action) options() source.Options {
+	tail := cmdln.GetEnv("MAXLOG_TAIL", "40")
+	if act.tail != "" {
+		tail = act.tail
+	}
+	prefix := os.Getenv("MAXLOG_PREFIX")
+	if act.prefix != "" {
+		prefix = act.prefix
+	}
+	window := cmdln.GetEnv("MAXLOG_MERGE_WINDOW", "1s")
+	if act.window != "" {
+		window = act.window
+	}
+	mergeWindow, err := time.ParseDuration(window)
+	if err != nil {
+		cmdln.Fatal("Error parsing merge window:", err)
+	}
+	exclude := os.Getenv("MAXLOG_EXCLUDE")
+	if act.exclude != "" {
+		exclude = act.exclude
+	}
+	filter, err := record.NewFilter(cmdln.Focus, exclude)
+	if err != nil {
+		cmdln.Fatal(err.Error(), nil)
+	}
+	now := time.Now()
+	since := parseTime(act.since, "MAXLOG_SINCE", now, time.Time{})
+	start := since
+	if start.IsZero() {
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	until := parseTime(act.until, "MAXLOG_UNTIL", now, start)
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		cmdln.Fatal("The time window is empty: until is before since.", nil)
+	}
+	return source.Options{
+		Namespace: act.namespace,
+		AppType:   act.apptype,
+		Tail:      tail,
+		Follow:    act.follow && !act.previous && (until.IsZero() || until.After(now)),
+		Tag:       act.tag,
+		File:      act.file,
+		Watch:     act.watch,
+		Previous:  act.previous,
+		Container: act.container,
+		InitCont:  act.initCont,
+		Profile:   act.profile,
+		Instance:  act.instance,
+		Selector:  act.selector,
+		Pod:       act.pod,
+		Prefix:    prefix,
+
+		FieldSelector: act.fields,
+		Context:       act.context,
+		Kubeconfig:    act.config,
+		Events:        act.events,
+		Label:         act.label,
+
+		Merge:       act.merge || act.events,
+		MergeWindow: mergeWindow,
+
+		Since: since,
+		Until: until,
+
+		Filter: filter,
+	}
+}
+
+// parseTime parses the since or until parameter.
+//
+// Parameters:
+//
+//	value - The value of the parameter.
+//	key   - The environment variable used if the parameter is not set.
+//	now   - The current time.
+//	after - The start of the time window for the until parameter, zero for the since parameter.
+//
+// Returns:
+//
+//	time.Time - The parsed time, or the zero time if neither is set.
+//
+// Behavior:
+//   - Logs a fatal error if the value is not a valid time, see cmdln.ParseTime.
+func parseTime(value string, key string, now time.Time, after time.Time) time.Time {
+	if value == "" {
+		value = os.Getenv(key)
+	}
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := cmdln.ParseTime(value, now, after)
+	if err != nil {
+		cmdln.Fatal(err.Error(), nil)
+	}
+	return t
+}
+
+// newSource creates the LogSource selected by the MAXLOG_MODE environment variable.
+//
+// Parameters:
+//
+//	opts - The options built by options, passed on to the LogSource.
+//
+// Returns:
+//
+//	source.LogSource - The created LogSource.
+//
+// Behavior:
+//   - Uses the stdin mode if "-" is given as argument or as file parameter.
+//   - Uses the file mode if the file parameter is given, even if MAXLOG_MODE is set.
+//   - Logs a fatal error if MAXLOG_MODE is not set, contains an invalid value or the source cannot be created.
+func (act *Action) newSource(opts source.Options) source.LogSource {
+	mode := os.Getenv("MAXLOG_MODE")
+	if act.stdin || act.file == "-" {
+		mode = "stdin"
+	} else if act.file != "" {
+		mode = "file"
+	}
+	if mode == "" {
+		cmdln.Fatal(" MAXLOG_MODE is not set. Please set it to one of: "+strings.Join(source.Names(), ", ")+".", nil)
+	}
+	src, err := source.New(mode, opts)
+	if err != nil {
+		cmdln.Fatal(err.Error(), nil)
+	}
+	return src
+}
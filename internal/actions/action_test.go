@@ -0,0 +1,40 @@
+package actions
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitSubCmd(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"tail=100"}, []string{"tail", "100"}},
+		{[]string{"--tag", "mytag"}, []string{"--tag", "mytag"}},
+		{[]string{"fieldSelector=spec.nodeName=worker1"}, []string{"fieldSelector", "spec.nodeName=worker1"}},
+		{[]string{"selector=app=myapp"}, []string{"selector", "app=myapp"}},
+		{[]string{"label=com.docker.compose.project=mas"}, []string{"label", "com.docker.compose.project=mas"}},
+		{[]string{"file=/tmp/x=y.log"}, []string{"file", "/tmp/x=y.log"}},
+		{[]string{"focus=/user=\\w+/"}, []string{"focus", "/user=\\w+/"}},
+		{[]string{"focus="}, []string{"focus", ""}},
+	}
+	for _, tt := range tests {
+		if got := splitSubCmd(tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("splitSubCmd(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestInitKeepsLabelValue(t *testing.T) {
+	act := &Action{}
+	if err := act.Init([]string{"label=com.docker.compose.project=mas", "selector=app=myapp"}); err != nil {
+		t.Fatal(err)
+	}
+	if act.label != "com.docker.compose.project=mas" {
+		t.Errorf("label = %q, want com.docker.compose.project=mas", act.label)
+	}
+	if act.selector != "app=myapp" {
+		t.Errorf("selector = %q, want app=myapp", act.selector)
+	}
+}
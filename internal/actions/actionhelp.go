@@ -40,16 +40,49 @@ func runHelp(act *Action) {
 	fmt.Println("  version    - Show version information")
 	fmt.Println("  help       - Show this help message")
 	fmt.Println("Example: maxlog logs --tag=mytag --tail=100")
+	fmt.Println("Example: maxlog logs previous=true  (logs of the previous container instance of restarted pods)")
+	fmt.Println("Example: oc logs mypod | maxlog - focus=error")
+	fmt.Println("Example: maxlog logs focus=\"error AND BMXAA,/OutOfMemory/\" exclude=/health/")
+	fmt.Println("Example: maxlog logs since=14:00 until=14:15  (only the lines of this time window)")
+	fmt.Println("Example: maxlog inspect --output=json  (table of the pods or containers as JSON)")
 	fmt.Println("If no action is set, logs will be used.")
 	fmt.Println("Environment variables:")
-	fmt.Println("  MAXLOG_MODE        - Set to 'k8s' for Kubernetes mode or 'pod' for podman mode")
+	fmt.Println("  MAXLOG_MODE        - Set to 'k8s' for Kubernetes mode, 'pod' for podman mode 'file' for local log files or 'stdin' for the standard input")
 	fmt.Println("  Podman mode")
-	fmt.Println("  MAXLOG_CONTAINER   - Specify the container name in podman mode")
+	fmt.Println("  MAXLOG_CONTAINER   - Specify the container name, a part of it or the ID in podman mode")
+	fmt.Println("                       If it is not set or ambiguous, inspect lists the candidate containers")
+	fmt.Println("                       Comma-separated names, glob patterns or /regex/ stream several containers. Also: container=")
+	fmt.Println("  MAXLOG_HOST        - Podman or Docker endpoint, e.g. unix:///run/podman/podman.sock. Default: DOCKER_HOST or the first socket found")
+	fmt.Println("  MAXLOG_CONTAINER_LABEL - Comma-separated label filters, e.g. com.docker.compose.project=mas. Also: label=")
+	fmt.Println("  File mode")
+	fmt.Println("  MAXLOG_FILE        - Comma-separated log files, directories or glob patterns. Also: file=")
+	fmt.Println("                       .gz files are decompressed. tail=all reads the complete files.")
 	fmt.Println("  K8s mode")
-	fmt.Println("  MAXLOG_K8S_NAMESPACE  - Namespace for Kubernetes logs")
+	fmt.Println("  MAXLOG_K8S_NAMESPACE  - Namespace for Kubernetes logs. Inside a pod, the namespace of the pod is the default")
+	fmt.Println("                          Comma-separated namespaces or glob patterns, e.g. mas-*-manage, prefix every line with the namespace")
 	fmt.Println("  MAXLOG_K8S_APPTYPE  - This is the pod selector for Kubernetes logs. E.g. all, ui")
+	fmt.Println("  MAXLOG_K8S_CONTEXT  - Comma-separated kubeconfig contexts. Several contexts prefix every line with the cluster. Also: context=")
+	fmt.Println("  MAXLOG_KUBECONFIG  - Path of the kubeconfig file (default: KUBECONFIG or ~/.kube/config). Also: kubeconfig=")
+	fmt.Println("  MAXLOG_K8S_PROFILE  - Selector profile: manage, core, iot, monitor, health or operators. Also: profile=")
+	fmt.Println("                        Sets namespace, selector and container. Own profiles: MAXLOG_PROFILE_<NAME>=\"namespace;selector;container\"")
+	fmt.Println("  MAXLOG_MAS_INSTANCE  - MAS instance ID filled into the profile's {instance} placeholder. Also: instance=")
+	fmt.Println("  MAXLOG_K8S_SELECTOR  - Raw label selector replacing the app types, e.g. app=myapp. Also: selector=")
+	fmt.Println("  MAXLOG_K8S_FIELD_SELECTOR  - Field selector, e.g. spec.nodeName=worker1. Also: fieldSelector=")
+	fmt.Println("  MAXLOG_K8S_POD  - Glob pattern or /regex/ the pod names must match. Also: pod=")
+	fmt.Println("  MAXLOG_K8S_CONTAINER  - Comma-separated containers or patterns inside the pods, or all. Also: container=")
+	fmt.Println("                          Default: the container named like the app type. initContainers=true adds the init containers.")
+	fmt.Println("  MAXLOG_K8S_EVENTS  - Show the Kubernetes events of the pods, e.g. OOM kills, in timestamp order. Also: events=true")
+	fmt.Println("  MAXLOG_WATCH  - Attach to new and restarted pods while following. Also: watch=true")
+	fmt.Println("                  Default in Podman mode: reattach to a restarted or recreated container.")
 	fmt.Println("  Other")
 	fmt.Println("  MAXLOG_USE_NERDFONT - Shows symbols from NerdFont")
 	fmt.Println("  MAXLOG_TAIL - Number of log lines to display (default: 40)")
 	fmt.Println("  MAXLOG_FOCUS - It hides all lines that do not contain the word. It is not case-sensitive.")
+	fmt.Println("                 error,warn shows either, error AND BMXAA both, /BMXAA\\d{4}E/ is a regular expression. Also: focus=")
+	fmt.Println("  MAXLOG_EXCLUDE - Hides the lines matching the expression, same syntax as focus. Also: exclude=")
+	fmt.Println("  MAXLOG_SINCE, MAXLOG_UNTIL - Time window: RFC3339, a clock time like 14:05 or a duration like 15m. Also: since=, until=")
+	fmt.Println("  MAXLOG_MERGE - Order the lines of all streams by timestamp. Also: merge=true")
+	fmt.Println("  MAXLOG_MERGE_WINDOW - Time a line is held back to wait for older lines (default: 1s). Also: window=")
+	fmt.Println("  MAXLOG_PREFIX - Prefix every line with its source: none (default), pod, name or container. Also: prefix=")
+	fmt.Println("                  Several Podman containers are prefixed with their name by default.")
 }
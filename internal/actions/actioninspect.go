@@ -1,12 +1,16 @@
 package actions
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/maxtoolbox/maxlog/internal/cmdln"
-	"github.com/maxtoolbox/maxlog/internal/k8s"
-	"github.com/maxtoolbox/maxlog/internal/moby"
+	"github.com/maxtoolbox/maxlog/internal/source"
 )
 
 // ActionInspect creates and initializes an Action for inspecting logs or resources.
@@ -26,61 +30,69 @@ func ActionInspect() *Action {
 	return act
 }
 
-// runInspect executes the inspection logic based on the MAXLOG_MODE environment variable.
+// inspectResult is the JSON output of the inspect action.
+type inspectResult struct {
+	Properties []source.Property `json:"properties"`
+	Targets    any               `json:"targets,omitempty"`
+}
+
+// runInspect displays information about the LogSource selected by the MAXLOG_MODE environment variable.
 //
 // Parameters:
 //
 //	act - A pointer to the Action instance.
 //
 // Behavior:
-//   - Reads the MAXLOG_MODE environment variable to determine the mode of operation.
-//   - Calls inspectK8s if the mode is "k8s".
-//   - Calls inspectPod if the mode is "pod".
-//   - Logs a fatal error if MAXLOG_MODE is not set or contains an invalid value.
+//   - Builds the options once and creates the LogSource with them using newSource.
+//   - Displays the properties returned by the source's Describe method and the tail parameter.
+//   - Displays a table of the targets if the source is a Detailer.
+//   - Writes the properties and targets as JSON instead if the output parameter is json.
+//   - Logs a fatal error if the source cannot be described or the output format is unknown.
 func runInspect(act *Action) {
-	mode := os.Getenv("MAXLOG_MODE")
-	if mode == "" {
-		cmdln.Fatal(" MAXLOG_MODE is not set. Please set it to 'k8s' for Kubernetes mode or 'pod' for podman.", nil)
-	} else if mode == "k8s" {
-		inspectK8s()
-	} else if mode == "pod" {
-		inspectPod()
-	} else {
-		cmdln.Fatal("Unknown mode: '"+mode+"'. Please set MAXLOG_MODE to 'k8s' or 'pod'.", nil)
+	if act.output != "" && act.output != "text" && act.output != "json" {
+		cmdln.Fatal("Unknown output format: '"+act.output+"'. Please use text or json.", nil)
 	}
-}
 
-// inspectK8s retrieves and displays information about Kubernetes resources.
-//
-// Behavior:
-//   - Reads the MAXLOG_K8S_NAMESPACE and MAXLOG_K8S_APPTYPE environment variables.
-//   - Logs a fatal error if the required environment variables are not set.
-//   - Retrieves the list of pods using the k8s.GetPods function.
-//   - Displays the namespace, application type, tail parameter, and the number of selected pods.
-func inspectK8s() {
-	ns := os.Getenv("MAXLOG_K8S_NAMESPACE")
-	apptype := cmdln.GetEnv("MAXLOG_K8S_APPTYPE", cmdln.DefaultLabels)
-	fmt.Println("Namespace    :", ns)
-	fmt.Println("AppType      :", apptype)
-	fmt.Println("Tail         :", os.Getenv("MAXLOG_TAIL"))
-	if len(ns) == 0 {
-		cmdln.Fatal("Please set MAXLOG_K8S_NAMESPACE environment variables.", nil)
-	}
-	pods, err := k8s.GetPods()
+	opts := act.options()
+	src := act.newSource(opts)
+	props, err := src.Describe(context.TODO())
 	if err != nil {
-		cmdln.Fatal(" Error getting pods: ", err)
+		cmdln.Fatal(err.Error(), nil)
+	}
+	props = append(props, source.Property{Key: "Tail", Value: opts.Tail})
+	if !opts.Since.IsZero() {
+		props = append(props, source.Property{Key: "Since", Value: opts.Since.Local().Format(time.DateTime)})
+	}
+	if !opts.Until.IsZero() {
+		props = append(props, source.Property{Key: "Until", Value: opts.Until.Local().Format(time.DateTime)})
 	}
-	fmt.Println("Selected Pods:", len(pods.Items))
-}
 
-// inspectPod retrieves and displays information about a specific container.
-//
-// Behavior:
-//   - Reads the MAXLOG_CONTAINER and MAXLOG_TAIL environment variables.
-//   - Retrieves the container ID using the moby.GetCID function.
-//   - Displays the container name, container ID, and tail parameter.
-func inspectPod() {
-	fmt.Println("Container    :", os.Getenv("MAXLOG_CONTAINER"))
-	fmt.Println("CID          :", moby.GetCID(os.Getenv("MAXLOG_CONTAINER")))
-	fmt.Println("Tail         :", os.Getenv("MAXLOG_TAIL"))
+	var details source.Details
+	if detailer, ok := src.(source.Detailer); ok {
+		if details, err = detailer.Details(context.TODO()); err != nil {
+			cmdln.Fatal(err.Error(), nil)
+		}
+	}
+
+	if act.output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(inspectResult{Properties: props, Targets: details.Items}); err != nil {
+			cmdln.Fatal("Error writing JSON:", err)
+		}
+		return
+	}
+
+	for _, prop := range props {
+		fmt.Printf("%-13s: %s\n", prop.Key, prop.Value)
+	}
+	if len(details.Rows) > 0 {
+		fmt.Println()
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, strings.Join(details.Columns, "\t"))
+		for _, row := range details.Rows {
+			fmt.Fprintln(table, strings.Join(row, "\t"))
+		}
+		table.Flush()
+	}
 }
@@ -1,11 +1,13 @@
 package actions
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/maxtoolbox/maxlog/internal/cmdln"
-	"github.com/maxtoolbox/maxlog/internal/k8s"
-	"github.com/maxtoolbox/maxlog/internal/moby"
+	"github.com/maxtoolbox/maxlog/internal/source"
 )
 
 // ActionLogs creates and initializes an Action for retrieving logs.
@@ -25,43 +27,24 @@ func ActionLogs() *Action {
 	return act
 }
 
-// runLogs retrieves logs based on the MAXLOG_MODE environment variable.
+// runLogs retrieves logs from the LogSource selected by the MAXLOG_MODE environment variable.
 //
 // Parameters:
 //
 //	act - A pointer to the Action instance.
 //
 // Behavior:
-//   - Reads the MAXLOG_MODE environment variable to determine the mode of operation.
-//   - If the mode is "k8s", retrieves logs for Kubernetes resources using the k8s.GetLog function.
-//   - If the mode is "pod", retrieves logs for a specific container using the moby.GetLog function.
-//   - Logs a fatal error if MAXLOG_MODE is not set or contains an invalid value.
+//   - Builds the options once and creates the LogSource with them using newSource.
+//   - Streams the logs of all targets of the source to the standard output.
+//   - Stops all streams on SIGINT (Ctrl-C) or SIGTERM.
+//   - Logs a fatal error if the logs cannot be retrieved.
 func runLogs(act *Action) {
-	tail := cmdln.GetEnv("MAXLOG_TAIL", "40")
-	if act.tail != "" {
-		tail = act.tail
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if os.Getenv("MAXLOG_MODE") == "k8s" {
-		selector := cmdln.GetEnv("MAXLOG_K8S_APPTYPE", cmdln.DefaultLabels)
-		namespace := os.Getenv("MAXLOG_K8S_NAMESPACE")
-		/* tag := ""
-		   if len(os.Args) > 3 {
-		       if os.Args[2] == "tag" {
-		           tag = os.Args[3]
-		       }
-		   } */
-		if namespace == "" || selector == "" {
-			cmdln.Fatal("Please set MAXLOG_K8S_NAMESPACE environment variables.", nil)
-		}
-		k8s.GetLog(tail, act.follow, act.tag)
-	} else if os.Getenv("MAXLOG_MODE") == "pod" {
-		container := os.Getenv("MAXLOG_CONTAINER")
-		if container == "" {
-			cmdln.Fatal("Container name is not set. Please set MAXLOG_CONTAINER environment variable.", nil)
-		}
-		moby.GetLog(container, tail, act.follow, act.tag)
-	} else {
-		cmdln.Fatal("Unknown MAXLOG_MODE. Please set it to 'k8s' or 'pod'.", nil)
+	opts := act.options()
+	src := act.newSource(opts)
+	if err := source.Stream(ctx, src, opts, os.Stdout); err != nil {
+		cmdln.Fatal(err.Error(), nil)
 	}
 }
@@ -1,8 +1,10 @@
 package cmdln
 
 import (
+	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 const (
@@ -28,16 +30,53 @@ func GetEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// ContainsIgnoreCase checks if string `b` is a substring of string `a`, ignoring case.
+// ParseTime parses a point in time given on the command line.
 //
 // Parameters:
 //
-//	a - The string to search within.
-//	b - The substring to search for.
+//	value - The time: RFC3339, e.g. 2025-10-16T14:05:00Z, a local date and time, e.g. 2025-10-16 14:05,
+//	        a local clock time, e.g. 14:05 or 14:05:30, or a duration before now, e.g. 15m or 2h30m.
+//	now   - The current time.
+//	after - For the end of a time window, the time it follows, e.g. its start. Zero for the start of a time window.
 //
 // Returns:
 //
-//	bool - true if `b` is a case-insensitive substring of `a`, otherwise false.
-func ContainsIgnoreCase(a, b string) bool {
-	return strings.Contains(strings.ToLower(a), strings.ToLower(b))
+//	time.Time - The parsed time.
+//	error - An error if the value has none of these formats.
+//
+// Behavior:
+//   - Without after, a clock time refers to today, or to yesterday if it is still to come today.
+//   - With after, a clock time refers to its first occurrence from after on, which may still be to come,
+//     e.g. until=14:15 after since=14:00 at 14:10.
+func ParseTime(value string, now time.Time, after time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if clock, err := time.Parse(layout, value); err == nil {
+			if !after.IsZero() {
+				after = after.In(now.Location())
+				t := time.Date(after.Year(), after.Month(), after.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+				if t.Before(after) {
+					t = t.AddDate(0, 0, 1)
+				}
+				return t, nil
+			}
+			t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+			if t.After(now) {
+				t = t.AddDate(0, 0, -1)
+			}
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid time '%s'. Please use RFC3339, e.g. 2025-10-16T14:05:00Z, a clock time, e.g. 14:05, or a duration, e.g. 15m.", value)
 }
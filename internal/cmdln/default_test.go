@@ -0,0 +1,53 @@
+package cmdln
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2025, 10, 16, 14, 10, 0, 0, time.Local)
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2025, 10, day, hour, minute, second, 0, time.Local)
+	}
+	tests := []struct {
+		name    string
+		value   string
+		after   time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC3339", "2025-10-16T12:05:00Z", time.Time{}, time.Date(2025, 10, 16, 12, 5, 0, 0, time.UTC), false},
+		{"RFC3339 with offset", "2025-10-16T14:05:00.5+02:00", time.Time{}, time.Date(2025, 10, 16, 12, 5, 0, 5e8, time.UTC), false},
+		{"local date and time", "2025-10-15 09:30", time.Time{}, at(15, 9, 30, 0), false},
+		{"local date and time with T and seconds", "2025-10-15T09:30:15", time.Time{}, at(15, 9, 30, 15), false},
+		{"local date", "2025-10-15", time.Time{}, at(15, 0, 0, 0), false},
+		{"past clock time", "14:00", time.Time{}, at(16, 14, 0, 0), false},
+		{"clock time with seconds", "09:05:30", time.Time{}, at(16, 9, 5, 30), false},
+		{"clock time still to come", "14:15", time.Time{}, at(15, 14, 15, 0), false},
+		{"clock time equal to now", "14:10", time.Time{}, at(16, 14, 10, 0), false},
+		{"until after since", "14:15", at(16, 14, 0, 0), at(16, 14, 15, 0), false},
+		{"until across midnight", "00:10", at(15, 23, 50, 0), at(16, 0, 10, 0), false},
+		{"until equal to since", "14:00", at(16, 14, 0, 0), at(16, 14, 0, 0), false},
+		{"until with date ignores since", "2025-10-15 09:30", at(16, 14, 0, 0), at(15, 9, 30, 0), false},
+		{"duration", "15m", time.Time{}, at(16, 13, 55, 0), false},
+		{"compound duration", "2h30m", time.Time{}, at(16, 11, 40, 0), false},
+		{"duration ignores since", "15m", at(16, 14, 0, 0), at(16, 13, 55, 0), false},
+		{"surrounding spaces", " 14:00 ", time.Time{}, at(16, 14, 0, 0), false},
+		{"negative duration", "-15m", time.Time{}, time.Time{}, true},
+		{"invalid clock time", "25:00", time.Time{}, time.Time{}, true},
+		{"empty", "", time.Time{}, time.Time{}, true},
+		{"unknown", "yesterday", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTime(tt.value, now, tt.after)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
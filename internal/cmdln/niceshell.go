@@ -1,6 +1,7 @@
 package cmdln
 
 import (
+	"hash/fnv"
 	"log"
 	"strconv"
 	"strings"
@@ -65,6 +66,12 @@ var (
 		0: Red + "[ERROR]" + Reset,
 		1: Yellow + "[WARNING]" + Reset,
 	}
+
+	// Colors for source prefixes. Red is left out, as it marks errors.
+	sourceColors = []string{
+		Cyan, Green, Yellow, Blue, Magenta,
+		LightCyan, LightGreen, LightYellow, LightBlue, LightMagenta,
+	}
 )
 
 // GetFocus retrieves the value of the "MAXLOG_FOCUS" environment variable.
@@ -106,6 +113,21 @@ func GetSymbol(id int) string {
 	return ascSymbols[id]
 }
 
+// SourceColor picks a stable foreground color for the name of a log source.
+//
+// Parameter:
+//
+//	name - The name of the pod or container.
+//
+// Returns:
+//
+//	string - The color, always the same for the same name.
+func SourceColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return sourceColors[h.Sum32()%uint32(len(sourceColors))]
+}
+
 // Fatal logs a fatal error message and terminates the program.
 //
 // Parameters:
@@ -164,16 +186,11 @@ func SetLabels(text, tag string) string {
 		{"[MAXIMO_UI]", "UI", SetMagentaLabel},
 		{"[maximo]", "MAX", SetCyanLabel},
 		{"[DEBUG]", "DEBUG", SetCyanLabel},
+		{"[EVENT]", "EVENT", SetMagentaLabel},
 		{"[maximo.script." + tag + "]", "Script", SetLightBlueLabel},
 		{"Maximo is ready for client connections.", "Maximo is ready for client connections.", SetGreenLabel},
 	}
 
-	if Focus != "" {
-		if !ContainsIgnoreCase(text, Focus) {
-			return ""
-		}
-	}
-
 	for _, r := range replacements {
 		text = r.labelFunc(text, r.oldKey, r.newKey)
 	}
@@ -204,11 +221,26 @@ func SetLabels(text, tag string) string {
 //
 //	string - The processed text with the formatted label.
 func SetLabel(text, oldKey, newKey, color, bgColor string) string {
+	return strings.Replace(text, oldKey, Label(newKey, color, bgColor), 1)
+}
+
+// Label formats a text as a color-coded label.
+//
+// Parameters:
+//
+//	text    - The text of the label.
+//	color   - The foreground color of the label's edges.
+//	bgColor - The background color of the label.
+//
+// Returns:
+//
+//	string - The formatted label, with Nerd Font edges if enabled.
+func Label(text, color, bgColor string) string {
 	prefix, suffix := ASCPrefixLabel, ASCSuffixLabel
 	if useNerdFont {
 		prefix, suffix = NFPrefixLabel, NFSuffixLabel
 	}
-	return strings.Replace(text, oldKey, color+prefix+Reset+bgColor+newKey+Reset+color+suffix+Reset, 1)
+	return color + prefix + Reset + bgColor + text + Reset + color + suffix + Reset
 }
 
 // SetYellowLabel applies a yellow color-coded label to a specific substring in the input text.
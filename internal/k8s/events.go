@@ -0,0 +1,209 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// eventsID marks the target streaming the Kubernetes events instead of a container log.
+const eventsID = "events"
+
+// eventsTarget returns the target streaming the events of the selected pods.
+//
+// Returns:
+//
+//	source.Target - The events target of the source.
+func (src *Source) eventsTarget() source.Target {
+	return source.Target{
+		Name:        "events",
+		ID:          eventsID,
+		Cluster:     src.cluster,
+		Namespace:   src.nsLabel,
+		Timestamped: true,
+	}
+}
+
+// openEvents opens a stream of the Kubernetes events of the selected pods.
+//
+// Parameters:
+//
+//	ctx    - The context for the stream.
+//	target - The events target.
+//
+// Returns:
+//
+//	io.ReadCloser - The stream of event lines, each starting with an RFC3339 timestamp.
+//	error - An error if the events cannot be listed.
+//
+// Behavior:
+//   - Lists the events of the pods in the namespace, ordered by time, and keeps those of the selected pods.
+//   - Skips events before the target's time if it is set, e.g. when resuming, otherwise before the since option.
+//   - Watches for new events if following, starting at the list.
+func (src *Source) openEvents(ctx context.Context, target source.Target) (io.ReadCloser, error) {
+	events := src.clientset.CoreV1().Events(src.namespace)
+	listOptions := metav1.ListOptions{FieldSelector: "involvedObject.kind=Pod"}
+	list, err := events.List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing events: %w", err)
+	}
+
+	selected, err := src.selectedPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(list.Items, func(a, b corev1.Event) int {
+		return eventTime(&a).Compare(eventTime(&b))
+	})
+
+	since := src.sinceTime(target)
+	pr, pw := io.Pipe()
+	go func() {
+		for i := range list.Items {
+			if event := &list.Items[i]; eventTime(event).Before(since) || !src.eventSelected(ctx, selected, event) {
+				continue
+			}
+			if _, err := io.WriteString(pw, formatEvent(&list.Items[i])); err != nil {
+				return
+			}
+		}
+		if !src.follow {
+			pw.Close()
+			return
+		}
+
+		listOptions.ResourceVersion = list.ResourceVersion
+		watcher, err := events.Watch(ctx, listOptions)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		defer watcher.Stop()
+		for change := range watcher.ResultChan() {
+			event, ok := change.Object.(*corev1.Event)
+			if !ok || change.Type == watch.Deleted || !src.eventSelected(ctx, selected, event) {
+				continue
+			}
+			if _, err := io.WriteString(pw, formatEvent(event)); err != nil {
+				return
+			}
+		}
+		pw.CloseWithError(ctx.Err())
+	}()
+	return pr, nil
+}
+
+// selectedPods lists the names of the selected pods.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	map[string]bool - Whether a pod is selected, by pod name.
+//	error - An error if the pods cannot be listed.
+func (src *Source) selectedPods(ctx context.Context) (map[string]bool, error) {
+	pods, err := src.GetPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting pods: %w", err)
+	}
+	selected := map[string]bool{}
+	for _, pod := range pods.Items {
+		selected[pod.Name] = true
+	}
+	return selected, nil
+}
+
+// eventSelected checks whether an event belongs to a selected pod.
+//
+// Parameters:
+//
+//	ctx      - The context for the request.
+//	selected - The known pods, by name. Pods looked up are added.
+//	event    - The event.
+//
+// Returns:
+//
+//	bool - true if the event belongs to a selected pod, otherwise false.
+//
+// Behavior:
+//   - Looks up pods created after the stream has been opened and checks them against the selectors and the pod filter.
+func (src *Source) eventSelected(ctx context.Context, selected map[string]bool, event *corev1.Event) bool {
+	name := event.InvolvedObject.Name
+	if known, found := selected[name]; found {
+		return known
+	}
+	pods, err := src.pods.List(ctx, metav1.ListOptions{
+		LabelSelector: src.selector(),
+		FieldSelector: fieldsAnd(src.fields, "metadata.name="+name),
+	})
+	selected[name] = err == nil && len(pods.Items) > 0 && src.podFilter.match(name)
+	return selected[name]
+}
+
+// fieldsAnd combines two field selectors.
+//
+// Parameters:
+//
+//	a - The first field selector, may be empty.
+//	b - The second field selector.
+//
+// Returns:
+//
+//	string - A field selector matching both.
+func fieldsAnd(a string, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "," + b
+}
+
+// eventTime determines the time an event has last occurred.
+//
+// Parameters:
+//
+//	event - The event.
+//
+// Returns:
+//
+//	time.Time - The last timestamp, the event time or the first timestamp, whichever is set.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
+
+// formatEvent formats an event as a timestamped log line.
+//
+// Parameters:
+//
+//	event - The event.
+//
+// Returns:
+//
+//	string - The line, e.g. "2025-10-16T14:05:01Z [EVENT] [WARN] BackOff pod/all-x2k4q: Back-off restarting failed container".
+func formatEvent(event *corev1.Event) string {
+	level := ""
+	if event.Type == corev1.EventTypeWarning {
+		level = "[WARN] "
+	}
+	message := strings.ReplaceAll(strings.TrimSpace(event.Message), "\n", " ")
+	text := fmt.Sprintf("%s [EVENT] %s%s pod/%s: %s", eventTime(event).UTC().Format(time.RFC3339Nano), level, event.Reason, event.InvolvedObject.Name, message)
+	if event.Count > 1 {
+		text += fmt.Sprintf(" (x%d)", event.Count)
+	}
+	return text + "\n"
+}
@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// podFilter selects pods by name.
+type podFilter struct {
+	pattern string         // The pattern as given by the user.
+	regex   *regexp.Regexp // The compiled regular expression, nil for a glob pattern.
+}
+
+// newPodFilter compiles a pod filter.
+//
+// Parameters:
+//
+//	pattern - A glob pattern, e.g. *-all-*, or a regular expression between slashes, e.g. /-(all|ui)-/.
+//
+// Returns:
+//
+//	*podFilter - The filter, nil if the pattern is empty.
+//	error - An error if the pattern is invalid.
+func newPodFilter(pattern string) (*podFilter, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	filter := &podFilter{pattern: pattern}
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		regex, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pod filter '%s': %w", pattern, err)
+		}
+		filter.regex = regex
+	} else if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("Invalid pod filter '%s': %w", pattern, err)
+	}
+	return filter, nil
+}
+
+// match checks whether a pod name passes the filter.
+//
+// Parameters:
+//
+//	name - The name of the pod.
+//
+// Returns:
+//
+//	bool - true if the filter is nil or the name matches, otherwise false.
+func (filter *podFilter) match(name string) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.regex != nil {
+		return filter.regex.MatchString(name)
+	}
+	matched, _ := path.Match(filter.pattern, name)
+	return matched
+}
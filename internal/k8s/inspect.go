@@ -0,0 +1,319 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// buildVersion matches a Manage build version in an image tag, e.g. 9.0.5 or 8.7.3-20250101.
+var buildVersion = regexp.MustCompile(`^v?(\d+\.\d+\.\d+(?:[-.+][0-9A-Za-z.]+)?)`)
+
+// PodInfo describes a pod for the inspect action.
+type PodInfo struct {
+	Namespace       string          `json:"namespace"`
+	Name            string          `json:"name"`
+	Phase           string          `json:"phase"`
+	Ready           string          `json:"ready"`                     // The ready and total containers, e.g. 1/1.
+	Restarts        int32           `json:"restarts"`                  // The restarts of all containers.
+	LastTermination string          `json:"lastTermination,omitempty"` // The reason and exit code of the last termination, e.g. OOMKilled (137).
+	Created         time.Time       `json:"created"`
+	Node            string          `json:"node,omitempty"`
+	Version         string          `json:"version,omitempty"` // The Manage build version detected from the image tag of the default container.
+	Containers      []ContainerInfo `json:"containers"`
+}
+
+// ContainerInfo describes a container of a pod for the inspect action.
+type ContainerInfo struct {
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Tag      string            `json:"tag"`
+	Init     bool              `json:"init,omitempty"`
+	Requests map[string]string `json:"requests,omitempty"` // The resource requests, e.g. cpu: 500m.
+	Limits   map[string]string `json:"limits,omitempty"`   // The resource limits, e.g. memory: 8Gi.
+}
+
+// Details retrieves a table of the selected pods.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	source.Details - One row per pod and the PodInfo items for the JSON output.
+//	error - An error if the pods cannot be listed.
+func (src *Source) Details(ctx context.Context) (source.Details, error) {
+	infos, err := src.podInfos(ctx)
+	if err != nil {
+		return source.Details{}, err
+	}
+	return podTable(infos, false), nil
+}
+
+// Details retrieves a table of the selected pods of all sources.
+//
+// Parameters:
+//
+//	ctx - The context for the requests.
+//
+// Returns:
+//
+//	source.Details - One row per pod, including the namespace, and the PodInfo items for the JSON output.
+//	error - The first error of a source.
+func (multi *multiSource) Details(ctx context.Context) (source.Details, error) {
+	infos := []PodInfo{}
+	for _, src := range multi.sources {
+		srcInfos, err := src.podInfos(ctx)
+		if err != nil {
+			return source.Details{}, fmt.Errorf("%s: %w", src.scope(), err)
+		}
+		infos = append(infos, srcInfos...)
+	}
+	return podTable(infos, true), nil
+}
+
+// podInfos collects the details of the selected pods.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]PodInfo - The details of every selected pod.
+//	error - An error if the pods cannot be listed.
+func (src *Source) podInfos(ctx context.Context) ([]PodInfo, error) {
+	pods, err := src.GetPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting pods: %w", err)
+	}
+	infos := make([]PodInfo, 0, len(pods.Items))
+	for i := range pods.Items {
+		infos = append(infos, newPodInfo(&pods.Items[i]))
+	}
+	return infos, nil
+}
+
+// newPodInfo collects the details of a pod.
+//
+// Parameters:
+//
+//	pod - The pod.
+//
+// Returns:
+//
+//	PodInfo - The details of the pod.
+func newPodInfo(pod *corev1.Pod) PodInfo {
+	info := PodInfo{
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		Phase:     string(pod.Status.Phase),
+		Restarts:  podRestarts(pod),
+		Created:   pod.CreationTimestamp.Time,
+		Node:      pod.Spec.NodeName,
+	}
+
+	ready := 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		if last := status.LastTerminationState.Terminated; last != nil {
+			info.LastTermination = fmt.Sprintf("%s (%d)", last.Reason, last.ExitCode)
+		}
+	}
+	info.Ready = fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+
+	for _, container := range pod.Spec.InitContainers {
+		info.Containers = append(info.Containers, newContainerInfo(container, true))
+	}
+	main := defaultContainer(pod)
+	for _, container := range pod.Spec.Containers {
+		containerInfo := newContainerInfo(container, false)
+		if container.Name == main {
+			if m := buildVersion.FindStringSubmatch(containerInfo.Tag); m != nil {
+				info.Version = m[1]
+			}
+		}
+		info.Containers = append(info.Containers, containerInfo)
+	}
+	return info
+}
+
+// newContainerInfo collects the details of a container.
+//
+// Parameters:
+//
+//	container - The container of the pod spec.
+//	init      - Whether it is an init container.
+//
+// Returns:
+//
+//	ContainerInfo - The details of the container.
+func newContainerInfo(container corev1.Container, init bool) ContainerInfo {
+	info := ContainerInfo{
+		Name:  container.Name,
+		Image: container.Image,
+		Tag:   imageTag(container.Image),
+		Init:  init,
+	}
+	if len(container.Resources.Requests) > 0 {
+		info.Requests = map[string]string{}
+		for name, quantity := range container.Resources.Requests {
+			info.Requests[string(name)] = quantity.String()
+		}
+	}
+	if len(container.Resources.Limits) > 0 {
+		info.Limits = map[string]string{}
+		for name, quantity := range container.Resources.Limits {
+			info.Limits[string(name)] = quantity.String()
+		}
+	}
+	return info
+}
+
+// podTable formats the details of pods as a table.
+//
+// Parameters:
+//
+//	infos         - The details of the pods.
+//	withNamespace - Whether to show the namespace column.
+//
+// Returns:
+//
+//	source.Details - The table and the items for the JSON output.
+//
+// Behavior:
+//   - Shows the image tags of the containers and the CPU and memory requests/limits summed over the containers.
+func podTable(infos []PodInfo, withNamespace bool) source.Details {
+	details := source.Details{
+		Columns: []string{"NAME", "PHASE", "READY", "RESTARTS", "LAST TERMINATION", "AGE", "NODE", "IMAGE", "CPU", "MEMORY", "VERSION"},
+		Items:   infos,
+	}
+	if withNamespace {
+		details.Columns = append([]string{"NAMESPACE"}, details.Columns...)
+	}
+
+	for _, info := range infos {
+		tags := []string{}
+		for _, container := range info.Containers {
+			if !container.Init {
+				tags = append(tags, container.Tag)
+			}
+		}
+		row := []string{
+			info.Name,
+			info.Phase,
+			info.Ready,
+			strconv.Itoa(int(info.Restarts)),
+			orDash(info.LastTermination),
+			age(time.Since(info.Created)),
+			orDash(info.Node),
+			strings.Join(tags, ","),
+			resources(info.Containers, corev1.ResourceCPU),
+			resources(info.Containers, corev1.ResourceMemory),
+			orDash(info.Version),
+		}
+		if withNamespace {
+			row = append([]string{info.Namespace}, row...)
+		}
+		details.Rows = append(details.Rows, row)
+	}
+	return details
+}
+
+// resources sums a resource over the containers of a pod.
+//
+// Parameters:
+//
+//	containers - The containers of the pod. Init containers are ignored.
+//	name       - The resource, e.g. cpu.
+//
+// Returns:
+//
+//	string - The requests and limits, e.g. 500m/2, with - if not set.
+func resources(containers []ContainerInfo, name corev1.ResourceName) string {
+	sum := func(values func(ContainerInfo) map[string]string) string {
+		total := resource.Quantity{}
+		found := false
+		for _, container := range containers {
+			if value, ok := values(container)[string(name)]; ok && !container.Init {
+				if quantity, err := resource.ParseQuantity(value); err == nil {
+					total.Add(quantity)
+					found = true
+				}
+			}
+		}
+		if !found {
+			return "-"
+		}
+		return total.String()
+	}
+	return sum(func(c ContainerInfo) map[string]string { return c.Requests }) + "/" +
+		sum(func(c ContainerInfo) map[string]string { return c.Limits })
+}
+
+// imageTag extracts the tag or the short digest of an image reference.
+//
+// Parameters:
+//
+//	image - The image reference, e.g. cp.icr.io/cp/manage/manage-all:9.0.5.
+//
+// Returns:
+//
+//	string - The tag, e.g. 9.0.5, otherwise @ and the first 12 characters of the digest, otherwise latest.
+func imageTag(image string) string {
+	image, digest, _ := strings.Cut(image, "@")
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, found := strings.Cut(name, ":"); found {
+		return tag
+	}
+	if digest != "" {
+		digest = strings.TrimPrefix(digest, "sha256:")
+		return "@" + digest[:min(12, len(digest))]
+	}
+	return "latest"
+}
+
+// age formats the age of a pod like kubectl.
+//
+// Parameters:
+//
+//	d - The age.
+//
+// Returns:
+//
+//	string - The age, e.g. 3d4h, 5h12m or 42m.
+func age(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+// orDash replaces an empty value for the table.
+//
+// Parameters:
+//
+//	value - The value.
+//
+// Returns:
+//
+//	string - The value, or - if it is empty.
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+)
+
+// multiSource is a LogSource combining the Sources of several clusters and namespaces.
+type multiSource struct {
+	sources []*Source // The combined sources.
+}
+
+// Targets lists the targets of all sources concurrently.
+//
+// Parameters:
+//
+//	ctx - The context for the requests.
+//
+// Returns:
+//
+//	[]source.Target - The targets of all sources, in the order of the sources.
+//	error - The first error of a source.
+func (multi *multiSource) Targets(ctx context.Context) ([]source.Target, error) {
+	targets := make([][]source.Target, len(multi.sources))
+	errs := make([]error, len(multi.sources))
+	var wg sync.WaitGroup
+	for i, src := range multi.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			targets[i], errs[i] = src.Targets(ctx)
+		}()
+	}
+	wg.Wait()
+
+	all := []source.Target{}
+	for i := range multi.sources {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", multi.sources[i].scope(), errs[i])
+		}
+		all = append(all, targets[i]...)
+	}
+	return all, nil
+}
+
+// Open opens the log stream of a target using the source it belongs to.
+//
+// Parameters:
+//
+//	ctx    - The context for the stream.
+//	target - The target to read from.
+//
+// Returns:
+//
+//	io.ReadCloser - The log stream of the target.
+//	error - An error if no source belongs to the target or the stream cannot be opened.
+func (multi *multiSource) Open(ctx context.Context, target source.Target) (io.ReadCloser, error) {
+	for _, src := range multi.sources {
+		if src.owns(target) {
+			return src.Open(ctx, target)
+		}
+	}
+	return nil, fmt.Errorf("No source for %s", target.Name)
+}
+
+// Describe retrieves the properties of all sources, each preceded by its context if several clusters are streamed.
+//
+// Parameters:
+//
+//	ctx - The context for the requests.
+//
+// Returns:
+//
+//	[]source.Property - The properties shown by the inspect action.
+//	error - The first error of a source.
+func (multi *multiSource) Describe(ctx context.Context) ([]source.Property, error) {
+	props := []source.Property{}
+	for _, src := range multi.sources {
+		srcProps, err := src.Describe(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.scope(), err)
+		}
+		if src.cluster != "" {
+			props = append(props, source.Property{Key: "Context", Value: src.cluster})
+		}
+		props = append(props, srcProps...)
+	}
+	return props, nil
+}
+
+// Watch combines the watches of all sources.
+//
+// Parameters:
+//
+//	ctx - The context. The channel is closed when it is cancelled.
+//
+// Returns:
+//
+//	<-chan source.TargetEvent - The channel receiving the events of all sources.
+//	error - An error if a watch cannot be started.
+func (multi *multiSource) Watch(ctx context.Context) (<-chan source.TargetEvent, error) {
+	events := make(chan source.TargetEvent)
+	var wg sync.WaitGroup
+	for _, src := range multi.sources {
+		srcEvents, err := src.Watch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range srcEvents {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events, nil
+}
+
+// scope describes which part of the clusters a Source reads from.
+//
+// Returns:
+//
+//	string - The kubeconfig context and namespace of the source.
+func (src *Source) scope() string {
+	if src.cluster == "" {
+		return src.namespace
+	}
+	return src.cluster + "/" + src.namespace
+}
+
+// owns checks whether a target belongs to a Source.
+//
+// Parameters:
+//
+//	target - The target.
+//
+// Returns:
+//
+//	bool - true if the target has been listed by the source, otherwise false.
+func (src *Source) owns(target source.Target) bool {
+	return target.Cluster == src.cluster && target.Namespace == src.nsLabel
+}
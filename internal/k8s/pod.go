@@ -1,168 +1,660 @@
 package k8s
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/maxtoolbox/maxlog/internal/cmdln"
+	"github.com/maxtoolbox/maxlog/internal/source"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// defaultContainerAnnotation names the container kubectl uses if no container is given.
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// serviceAccountNamespace is the file holding the namespace of the service account inside a pod.
+const serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+func init() {
+	source.Register("k8s", NewSource)
+}
+
+// Source is a LogSource reading the logs of the Kubernetes pods selected by app type or profile.
+type Source struct {
+	namespace string                // The namespace of the pods.
+	apptype   string                // The comma-separated list of app types.
+	tail      string                // The number of lines to tail from the logs.
+	follow    bool                  // Whether to follow the log streams.
+	previous  bool                  // Whether to read the logs of the previous container instance.
+	container string                // The comma-separated container names or patterns. Empty selects the default container.
+	initCont  bool                  // Whether to include the init containers.
+	profile   string                // The name of the selector profile, if any.
+	labels    string                // The label selector of the pods.
+	fields    string                // The field selector of the pods, e.g. spec.nodeName=worker1.
+	podFilter *podFilter            // The filter on the pod names, nil if all pods are selected.
+	cluster   string                // The kubeconfig context shown in front of every line, empty for a single context.
+	nsLabel   string                // The namespace shown in front of every line, empty for a single namespace.
+	events    bool                  // Whether to stream the Kubernetes events of the selected pods.
+	since     time.Time             // The time the logs are requested from, zero for the tail.
+	until     time.Time             // The time the logs are shown up to. It is applied by the pipeline, as the API has no such option.
+	clientset *kubernetes.Clientset // The Kubernetes clientset.
+	pods      typedv1.PodInterface  // The pod client for the namespace.
+}
+
+// NewSource creates a Source for the Kubernetes mode.
+//
+// Parameters:
+//
+//	opts - The options of the action.
+//
+// Returns:
+//
+//	source.LogSource - The created Source.
+//	error - An error if the profile or the pod filter is invalid, the namespace is not set or the clientset cannot be created.
+//
+// Behavior:
+//   - Uses the selector profile of the profile option or MAXLOG_K8S_PROFILE, if set, for the namespace,
+//     label selector and container. The profile's placeholders are filled with the instance option or MAXLOG_MAS_INSTANCE.
+//   - Uses MAXLOG_K8S_NAMESPACE, MAXLOG_K8S_APPTYPE and MAXLOG_K8S_CONTAINER if the options and the profile leave them empty.
+//   - Uses the namespace of the service account when running inside a pod and no namespace is set.
+//   - Replaces the label selector by the selector option or MAXLOG_K8S_SELECTOR if set.
+//   - Uses the fieldSelector and pod options or MAXLOG_K8S_FIELD_SELECTOR and MAXLOG_K8S_POD to narrow the pods down.
+//   - Creates a Kubernetes clientset using GetClientSet for the context and kubeconfig options
+//     or MAXLOG_K8S_CONTEXT and MAXLOG_KUBECONFIG.
+//   - Creates a Source per context and namespace if several are given and combines them with a multiSource.
+//     The namespaces may be a comma-separated list or glob patterns.
+func NewSource(opts source.Options) (source.LogSource, error) {
+	src := &Source{
+		namespace: opts.Namespace,
+		apptype:   opts.AppType,
+		tail:      opts.Tail,
+		follow:    opts.Follow,
+		previous:  opts.Previous,
+		container: opts.Container,
+		initCont:  opts.InitCont,
+		profile:   opts.Profile,
+		fields:    opts.FieldSelector,
+		events:    opts.Events,
+		since:     opts.Since,
+		until:     opts.Until,
+	}
+	if src.profile == "" {
+		src.profile = os.Getenv("MAXLOG_K8S_PROFILE")
+	}
+	if src.apptype == "" {
+		src.apptype = cmdln.GetEnv("MAXLOG_K8S_APPTYPE", cmdln.DefaultLabels)
+	}
+	instance := opts.Instance
+	if instance == "" {
+		instance = os.Getenv("MAXLOG_MAS_INSTANCE")
+	}
+
+	selector := cmdln.AppTypeName + " in ({apptype})"
+	if src.profile != "" {
+		profile, err := GetProfile(src.profile)
+		if err != nil {
+			return nil, err
+		}
+		if src.namespace == "" {
+			if src.namespace, err = expand(profile.Namespace, instance, src.apptype); err != nil {
+				return nil, err
+			}
+		}
+		if src.container == "" {
+			src.container = profile.Container
+		}
+		selector = profile.Selector
+	}
+	if opts.Selector != "" {
+		selector = opts.Selector
+	} else if value := os.Getenv("MAXLOG_K8S_SELECTOR"); value != "" {
+		selector = value
+	}
+	labels, err := expand(selector, instance, src.apptype)
+	if err != nil {
+		return nil, err
+	}
+	src.labels = labels
+	if src.fields == "" {
+		src.fields = os.Getenv("MAXLOG_K8S_FIELD_SELECTOR")
+	}
+	pod := opts.Pod
+	if pod == "" {
+		pod = os.Getenv("MAXLOG_K8S_POD")
+	}
+	if src.podFilter, err = newPodFilter(pod); err != nil {
+		return nil, err
+	}
+
+	if src.namespace == "" {
+		src.namespace = os.Getenv("MAXLOG_K8S_NAMESPACE")
+	}
+	if src.namespace == "" {
+		src.namespace = inClusterNamespace()
+	}
+	if src.container == "" {
+		src.container = os.Getenv("MAXLOG_K8S_CONTAINER")
+	}
+	if src.namespace == "" {
+		return nil, fmt.Errorf("Please set MAXLOG_K8S_NAMESPACE environment variables.")
+	}
+
+	kubeconfig := opts.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = os.Getenv("MAXLOG_KUBECONFIG")
+	}
+	kubecontext := opts.Context
+	if kubecontext == "" {
+		kubecontext = os.Getenv("MAXLOG_K8S_CONTEXT")
+	}
+	contexts := strings.Split(kubecontext, ",")
+	tagNamespace := strings.ContainsAny(src.namespace, ",*?[")
+	sources := []*Source{}
+	for _, name := range contexts {
+		name = strings.TrimSpace(name)
+		clientset, err := GetClientSet(kubeconfig, name)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating clientset %s: %w", name, err)
+		}
+		namespaces, err := expandNamespaces(clientset, src.namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, namespace := range namespaces {
+			nsSrc := *src
+			nsSrc.namespace = namespace
+			nsSrc.clientset = clientset
+			nsSrc.pods = clientset.CoreV1().Pods(namespace)
+			if len(contexts) > 1 {
+				nsSrc.cluster = name
+			}
+			if tagNamespace {
+				nsSrc.nsLabel = namespace
+			}
+			sources = append(sources, &nsSrc)
+		}
+	}
+
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+	return &multiSource{sources: sources}, nil
+}
+
+// expandNamespaces resolves the comma-separated namespaces and glob patterns of the namespace option.
+//
+// Parameters:
+//
+//	clientset - The clientset of the cluster.
+//	patterns  - The namespaces or glob patterns, e.g. mas-inst-manage,mas-inst2-manage or mas-*-manage.
+//
+// Returns:
+//
+//	[]string - The namespaces.
+//	error - An error if the namespaces cannot be listed or a pattern matches no namespace.
+//
+// Behavior:
+//   - Lists the namespaces of the cluster only if a pattern contains a wildcard.
+func expandNamespaces(clientset *kubernetes.Clientset, patterns string) ([]string, error) {
+	var existing *corev1.NamespaceList
+	namespaces := []string{}
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if !strings.ContainsAny(pattern, "*?[") {
+			namespaces = append(namespaces, pattern)
+			continue
+		}
+
+		if existing == nil {
+			list, err := clientset.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("Error listing namespaces for '%s': %w", pattern, err)
+			}
+			existing = list
+		}
+		found := false
+		for _, namespace := range existing.Items {
+			if matched, _ := path.Match(pattern, namespace.Name); matched && !slices.Contains(namespaces, namespace.Name) {
+				namespaces = append(namespaces, namespace.Name)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("No namespace matches '%s'.", pattern)
+		}
+	}
+	return namespaces, nil
+}
+
 // GetClientSet creates and returns a Kubernetes clientset.
 //
+// Parameters:
+//
+//	kubeconfig  - The path of the kubeconfig file. Empty uses the default loading rules, i.e. KUBECONFIG or ~/.kube/config.
+//	kubecontext - The kubeconfig context. Empty uses the current context.
+//
 // Returns:
 //
 //	*kubernetes.Clientset - A clientset for interacting with the Kubernetes API.
-//	error - An error if the clientset creation fails.
+//	error - An error if the kubeconfig cannot be loaded or the clientset creation fails.
 //
 // Behavior:
 //   - Loads the default kubeconfig file using clientcmd.
-//   - Applies configuration overrides if necessary.
-//   - Creates a clientset using the loaded kubeconfig.
-//   - Terminates the program with a fatal error if the kubeconfig cannot be loaded.
-func GetClientSet() (*kubernetes.Clientset, error) {
+//   - Overrides the current context if a context is given.
+//   - Falls back to the in-cluster configuration of the service account if no kubeconfig can be loaded,
+//     e.g. when maxlog runs as a pod or Job. Not if a kubeconfig or context has been set explicitly.
+//   - Creates a clientset using the loaded configuration.
+func GetClientSet(kubeconfig string, kubecontext string) (*kubernetes.Clientset, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
+	loadingRules.ExplicitPath = kubeconfig
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubecontext}
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 	config, err := kubeConfig.ClientConfig()
+	if err != nil && kubeconfig == "" && kubecontext == "" {
+		if inCluster, inErr := rest.InClusterConfig(); inErr == nil {
+			config, err = inCluster, nil
+		}
+	}
 	if err != nil {
-		cmdln.Fatal("Error loading kubeconfig:", err)
+		return nil, fmt.Errorf("Error loading kubeconfig: %w", err)
 	}
 	return kubernetes.NewForConfig(config)
 }
 
-// GetNSPods retrieves the PodInterface for the specified Kubernetes namespace.
+// inClusterNamespace reads the namespace of the service account maxlog runs with inside a pod.
 //
 // Returns:
 //
-//	typedv1.PodInterface - An interface for interacting with pods in the namespace.
+//	string - The namespace, or an empty string if maxlog does not run inside a pod.
+func inClusterNamespace() string {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return ""
+	}
+	data, err := os.ReadFile(serviceAccountNamespace)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// GetPods retrieves a list of pods matching the label selector, the field selector and the pod filter.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	*corev1.PodList - A list of pods matching the selectors and the pod filter.
+//	error - An error if the pod retrieval fails.
 //
 // Behavior:
-//   - Creates a Kubernetes clientset using GetClientSet.
-//   - Retrieves the namespace from the environment variable MAXLOG_K8S_NAMESPACE.
-//   - Returns the PodInterface for the specified namespace.
-//   - Terminates the program with a fatal error if the clientset creation fails.
-func GetNSPods() typedv1.PodInterface {
-	clientset, err := GetClientSet()
+//   - Passes the label and field selectors to the API server.
+//   - Applies the pod filter to the returned list.
+func (src *Source) GetPods(ctx context.Context) (*corev1.PodList, error) {
+	listOptions := metav1.ListOptions{}
+	src.listOptions(&listOptions)
+	pods, err := src.pods.List(ctx, listOptions)
 	if err != nil {
-		cmdln.Fatal("Error creating clientset:", err)
+		return nil, err
 	}
-	project := os.Getenv("MAXLOG_K8S_NAMESPACE")
-	return clientset.CoreV1().Pods(project)
+	pods.Items = slices.DeleteFunc(pods.Items, func(pod corev1.Pod) bool {
+		return !src.podFilter.match(pod.Name)
+	})
+	return pods, nil
+}
+
+// listOptions sets the label and field selectors of a pod list or watch request.
+//
+// Parameters:
+//
+//	options - The options of the request.
+func (src *Source) listOptions(options *metav1.ListOptions) {
+	options.LabelSelector = src.selector()
+	options.FieldSelector = src.fields
 }
 
-// GetPods retrieves a list of pods matching the specified label selector.
+// selector returns the label selector of the pods.
 //
 // Returns:
 //
-//	*corev1.PodList - A list of pods matching the label selector.
-//	error - An error if the pod retrieval fails.
+//	string - The label selector, e.g. mas.ibm.com/appTypeName in (all, ui).
+func (src *Source) selector() string {
+	return src.labels
+}
+
+// podTargets lists the targets of a pod.
+//
+// Parameters:
+//
+//	pod - The pod.
+//
+// Returns:
+//
+//	[]source.Target - One target per selected container of the pod.
+//
+// Behavior:
+//   - Lists the init containers first if the initContainers option is set.
+//   - Marks terminated init containers as ended, so their streams are not resumed.
+func (src *Source) podTargets(pod *corev1.Pod) []source.Target {
+	targets := []source.Target{}
+	if src.initCont {
+		for _, container := range pod.Spec.InitContainers {
+			if src.container == "" || matchContainer(src.container, container.Name) {
+				targets = append(targets, source.Target{
+					Name:        pod.Name,
+					Container:   container.Name,
+					Cluster:     src.cluster,
+					Namespace:   src.nsLabel,
+					Timestamped: true,
+					Ended:       initTerminated(pod, container.Name),
+				})
+			}
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		if src.selected(pod, container.Name) {
+			targets = append(targets, source.Target{
+				Name:        pod.Name,
+				Container:   container.Name,
+				Cluster:     src.cluster,
+				Namespace:   src.nsLabel,
+				Timestamped: true,
+			})
+		}
+	}
+	return targets
+}
+
+// selected checks whether a container of a pod is selected by the container option.
+//
+// Parameters:
+//
+//	pod  - The pod.
+//	name - The name of the container.
+//
+// Returns:
+//
+//	bool - true if the container is selected, otherwise false.
 //
 // Behavior:
-//   - Constructs a label selector using the environment variable MAXLOG_K8S_APPTYPE.
-//   - Uses the PodInterface from GetNSPods to list pods in the namespace.
-//   - Returns the list of pods and any error encountered during retrieval.
-func GetPods() (*corev1.PodList, error) {
-	listOptions := metav1.ListOptions{
-		LabelSelector: cmdln.AppTypeName + " in (" + cmdln.GetEnv("MAXLOG_K8S_APPTYPE", cmdln.DefaultLabels) + ")",
+//   - Selects the default container of the pod if the container option is empty.
+func (src *Source) selected(pod *corev1.Pod, name string) bool {
+	if src.container == "" {
+		return name == defaultContainer(pod)
 	}
-	return GetNSPods().List(context.TODO(), listOptions)
+	return matchContainer(src.container, name)
 }
 
-// GetLog retrieves logs for Kubernetes pods and processes them.
+// matchContainer checks a container name against the container option.
+//
+// Parameters:
 //
-// Parameter:
+//	patterns - The comma-separated container names or glob patterns, e.g. maxinst,monitor*. all matches every container.
+//	name     - The name of the container.
 //
-//	tail - A string representing the number of lines to tail from the logs.
-//	follow - A boolean indicating whether to follow the log stream.
-//	tag - A string representing a tag to be applied to the log lines.
+// Returns:
+//
+//	bool - true if one of the patterns matches, otherwise false.
+func matchContainer(patterns string, name string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "all" {
+			return true
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultContainer determines the container shown if no container is selected.
+//
+// Parameters:
+//
+//	pod - The pod.
+//
+// Returns:
+//
+//	string - The name of the default container.
 //
 // Behavior:
-//   - Fetches the list of pods using the GetPods function.
-//   - Handles errors that occur during pod retrieval by terminating the program.
-//   - Passes the retrieved pods and tail parameter to the getPodLogs function for log processing.
-func GetLog(tail string, follow bool, tag string) {
-	pods, err := GetPods()
+//   - Uses the kubectl.kubernetes.io/default-container annotation if set.
+//   - Otherwise uses the container named like the app type, as the Manage server bundles do.
+//   - Otherwise uses the first container of the pod.
+func defaultContainer(pod *corev1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		return name
+	}
+	apptype := pod.Labels[cmdln.AppTypeName]
+	for _, container := range pod.Spec.Containers {
+		if container.Name == apptype {
+			return apptype
+		}
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
+
+// initTerminated checks whether an init container of a pod has terminated.
+//
+// Parameters:
+//
+//	pod  - The pod.
+//	name - The name of the init container.
+//
+// Returns:
+//
+//	bool - true if the init container has terminated, otherwise false.
+func initTerminated(pod *corev1.Pod, name string) bool {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name == name {
+			return status.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// Targets lists a target for every selected pod.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Target - One target per selected container of every pod, plus the events target if the events option is set.
+//	error - An error if the pods cannot be listed.
+func (src *Source) Targets(ctx context.Context) ([]source.Target, error) {
+	pods, err := src.GetPods(ctx)
 	if err != nil {
-		cmdln.Fatal("Error getting pods:", err)
+		return nil, fmt.Errorf("Error getting pods: %w", err)
+	}
+	targets := make([]source.Target, 0, len(pods.Items)+1)
+	for i := range pods.Items {
+		targets = append(targets, src.podTargets(&pods.Items[i])...)
 	}
-	getPodLogs(pods, tail, follow, tag)
+	if src.events && len(targets) > 0 {
+		targets = append(targets, src.eventsTarget())
+	}
+	return targets, nil
 }
 
-// getPodLogs retrieves and processes logs for a list of Kubernetes pods.
+// Open opens the log stream of a pod.
 //
 // Parameters:
 //
-//	pods - A pointer to a corev1.PodList containing the pods to retrieve logs from.
-//	tail - A string representing the number of lines to tail from the logs.
-//	follow - A boolean indicating whether to follow the log stream.
-//	tag - A string representing a tag to be applied to the log lines.
+//	ctx    - The context for the stream.
+//	target - The pod to read from.
+//
+// Returns:
+//
+//	io.ReadCloser - The log stream of the pod.
+//	error - An error if the tail number is invalid or the stream cannot be opened.
 //
 // Behavior:
-//   - Parses the `tail` parameter into an integer value.
+//   - Opens the events of the selected pods for the events target using openEvents.
+//   - Parses the tail option into an integer value.
 //   - Configures pod log options, including tailing the specified number of lines and following the logs.
-//   - Iterates through the list of pods and retrieves their logs using the Kubernetes client.
-//   - Starts a goroutine for each pod to process its logs using the `writeLogs` function.
-//   - Waits for all log processing goroutines to complete before returning.
-func getPodLogs(pods *corev1.PodList, tail string, follow bool, tag string) {
-	tailnum, err := strconv.ParseInt(tail, 10, 64)
+//   - Requests timestamps so the stream can be resumed.
+//   - Requests the log of the previous container instance if the previous option is set.
+//   - Requests the complete log if the target starts from the beginning or the since or until option is set.
+//   - Requests the log since the target's time if it is set, otherwise since the since option.
+func (src *Source) Open(ctx context.Context, target source.Target) (io.ReadCloser, error) {
+	if target.ID == eventsID {
+		return src.openEvents(ctx, target)
+	}
+
+	tailnum, err := strconv.ParseInt(src.tail, 10, 64)
 	if err != nil {
-		cmdln.Fatal("Error parsing tail number:", err)
+		return nil, fmt.Errorf("Error parsing tail number: %w", err)
 	}
 
 	podLogOpts := corev1.PodLogOptions{
-		Follow:    follow,
-		TailLines: &tailnum,
-		Container: "",
+		Follow:     src.follow,
+		TailLines:  &tailnum,
+		Container:  target.Container,
+		Timestamps: true,
+		Previous:   src.previous,
+	}
+	if target.FromStart || !src.since.IsZero() || !src.until.IsZero() {
+		podLogOpts.TailLines = nil
 	}
+	if since := src.sinceTime(target); !since.IsZero() {
+		sinceTime := metav1.NewTime(since)
+		podLogOpts.TailLines = nil
+		podLogOpts.SinceTime = &sinceTime
+	}
+	return src.pods.GetLogs(target.Name, &podLogOpts).Stream(ctx)
+}
 
-	ctx := context.TODO()
-	ch := make(chan bool)
+// sinceTime determines the time a stream starts at.
+//
+// Parameters:
+//
+//	target - The target of the stream.
+//
+// Returns:
+//
+//	time.Time - The target's time when resuming, otherwise the since option, zero for the tail.
+func (src *Source) sinceTime(target source.Target) time.Time {
+	if target.Since.IsZero() {
+		return src.since
+	}
+	return target.Since
+}
 
-	for _, pod := range pods.Items {
-		podLogOpts.Container = pod.Labels[cmdln.AppTypeName]
-		podLogs, err := GetNSPods().GetLogs(pod.Name, &podLogOpts).Stream(ctx)
-		if err != nil {
-			cmdln.Fatal("Error getting pod logs:", err)
+// Describe retrieves the profile, namespace, label selector, number of selected pods, their containers and the restarted pods.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Property - The properties shown by the inspect action.
+//	error - An error if the pods cannot be listed.
+func (src *Source) Describe(ctx context.Context) ([]source.Property, error) {
+	pods, err := src.GetPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting pods: %w", err)
+	}
+	props := []source.Property{}
+	if src.profile != "" {
+		props = append(props, source.Property{Key: "Profile", Value: src.profile})
+	}
+	props = append(props,
+		source.Property{Key: "Namespace", Value: src.namespace},
+		source.Property{Key: "Selector", Value: src.selector()},
+	)
+	if src.fields != "" {
+		props = append(props, source.Property{Key: "Field Selector", Value: src.fields})
+	}
+	if src.podFilter != nil {
+		props = append(props, source.Property{Key: "Pod Filter", Value: src.podFilter.pattern})
+	}
+	props = append(props,
+		source.Property{Key: "Selected Pods", Value: strconv.Itoa(len(pods.Items))},
+	)
+	for i := range pods.Items {
+		props = append(props, source.Property{Key: "Containers", Value: src.describeContainers(&pods.Items[i])})
+	}
+	for i := range pods.Items {
+		if restarts := describeRestarts(&pods.Items[i]); restarts != "" {
+			props = append(props, source.Property{Key: "Restarted", Value: restarts})
+		}
+	}
+	return props, nil
+}
+
+// describeContainers lists the containers of a pod for the inspect action.
+//
+// Parameters:
+//
+//	pod - The pod.
+//
+// Returns:
+//
+//	string - The pod name followed by its init containers and containers. Selected containers are marked with *.
+func (src *Source) describeContainers(pod *corev1.Pod) string {
+	selected := map[string]bool{}
+	for _, target := range src.podTargets(pod) {
+		selected[target.Container] = true
+	}
+	mark := func(name string) string {
+		if selected[name] {
+			return name + "*"
 		}
-		go writeLogs(bufio.NewReader(podLogs), ch, tag)
+		return name
 	}
 
-	<-ch
+	names := []string{}
+	for _, container := range pod.Spec.InitContainers {
+		names = append(names, "init:"+mark(container.Name))
+	}
+	for _, container := range pod.Spec.Containers {
+		names = append(names, mark(container.Name))
+	}
+	return pod.Name + ": " + strings.Join(names, ", ")
 }
 
-// writeLogs reads log lines from a buffered reader and processes them.
+// describeRestarts summarises the restarts of a pod for the inspect action.
 //
 // Parameters:
 //
-//	buffer - A pointer to a bufio.Reader that provides the log lines to read.
-//	ch - A channel used to signal when the log processing is complete.
-//	tag - A string representing a tag to be applied to the log lines.
+//	pod - The pod.
+//
+// Returns:
+//
+//	string - The pod name, restart count and last termination, or an empty string if the pod has not restarted.
 //
 // Behavior:
-//   - Continuously reads lines from the buffer until EOF is reached.
-//   - Each line is processed using the SetLabels function to apply formatting.
-//   - Outputs the formatted log lines to the standard output.
-//   - Signals completion by sending a value to the provided channel.
-func writeLogs(buffer *bufio.Reader, ch chan bool, tag string) {
-	defer func() { ch <- true }()
-
-	for {
-		line, err := buffer.ReadString('\n')
-		if err == io.EOF {
-			break
-		}
-		text := cmdln.SetLabels(line, tag)
-		if text != "" {
-			fmt.Print(text)
+//   - Hints at the previous option, which shows the log of the terminated container.
+func describeRestarts(pod *corev1.Pod) string {
+	restarts := podRestarts(pod)
+	if restarts == 0 {
+		return ""
+	}
+	text := fmt.Sprintf("%s: %d restarts", pod.Name, restarts)
+	for _, status := range pod.Status.ContainerStatuses {
+		if last := status.LastTerminationState.Terminated; last != nil {
+			text += fmt.Sprintf(", %s terminated with %s (exit code %d) at %s", status.Name, last.Reason, last.ExitCode, last.FinishedAt.Local().Format(time.DateTime))
 		}
 	}
+	return text + " - see previous=true"
 }
@@ -0,0 +1,119 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/maxtoolbox/maxlog/internal/cmdln"
+)
+
+// Profile describes where the pods of a MAS application run and how they are selected.
+//
+// The fields may contain the placeholders {instance} for the MAS instance ID and
+// {apptype} for the app types of the apptype option.
+type Profile struct {
+	Namespace string // The namespace of the pods, e.g. mas-{instance}-manage.
+	Selector  string // The label selector of the pods.
+	Container string // The containers inside the pods. Empty selects the default container.
+}
+
+// profiles holds the built-in profiles of the common MAS applications, by name.
+var profiles = map[string]Profile{
+	"manage": {
+		Namespace: "mas-{instance}-manage",
+		Selector:  cmdln.AppTypeName + " in ({apptype})",
+	},
+	"core": {
+		Namespace: "mas-{instance}-core",
+		Selector:  "mas.ibm.com/instanceId={instance}",
+	},
+	"iot": {
+		Namespace: "mas-{instance}-iot",
+		Selector:  "mas.ibm.com/applicationId=iot",
+	},
+	"monitor": {
+		Namespace: "mas-{instance}-monitor",
+		Selector:  "mas.ibm.com/applicationId=monitor",
+	},
+	"health": {
+		Namespace: "mas-{instance}-health",
+		Selector:  "mas.ibm.com/applicationId=health",
+	},
+	"operators": {
+		Namespace: "mas-{instance}-core",
+		Selector:  "control-plane=controller-manager",
+		Container: "manager",
+	},
+}
+
+// ProfileNames returns the sorted names of the built-in profiles.
+//
+// Returns:
+//
+//	[]string - The names of the built-in profiles.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetProfile looks up a profile by name.
+//
+// Parameters:
+//
+//	name - The name of the profile, not case-sensitive.
+//
+// Returns:
+//
+//	Profile - The profile.
+//	error - An error if the profile is neither user-defined nor built-in, or its definition is invalid.
+//
+// Behavior:
+//   - Prefers a user-defined profile from MAXLOG_PROFILE_<NAME>, e.g. MAXLOG_PROFILE_MYAPP.
+//     Its value is "namespace;selector;container", the container being optional.
+//   - Falls back to the built-in profiles, so they can be overridden.
+func GetProfile(name string) (Profile, error) {
+	name = strings.ToLower(name)
+	if value := os.Getenv("MAXLOG_PROFILE_" + strings.ToUpper(name)); value != "" {
+		parts := strings.Split(value, ";")
+		if len(parts) < 2 || len(parts) > 3 {
+			return Profile{}, fmt.Errorf("Invalid profile MAXLOG_PROFILE_%s. Please use \"namespace;selector;container\".", strings.ToUpper(name))
+		}
+		profile := Profile{Namespace: strings.TrimSpace(parts[0]), Selector: strings.TrimSpace(parts[1])}
+		if len(parts) == 3 {
+			profile.Container = strings.TrimSpace(parts[2])
+		}
+		return profile, nil
+	}
+
+	profile, found := profiles[name]
+	if !found {
+		return Profile{}, fmt.Errorf("Unknown profile: '%s'. Please use one of: %s or define MAXLOG_PROFILE_%s.", name, strings.Join(ProfileNames(), ", "), strings.ToUpper(name))
+	}
+	return profile, nil
+}
+
+// expand replaces the placeholders of a profile field.
+//
+// Parameters:
+//
+//	value    - The value of the field.
+//	instance - The MAS instance ID.
+//	apptype  - The comma-separated list of app types.
+//
+// Returns:
+//
+//	string - The value with the placeholders replaced.
+//	error - An error if the value needs the instance ID and it is not set.
+func expand(value string, instance string, apptype string) (string, error) {
+	if strings.Contains(value, "{instance}") && instance == "" {
+		return "", fmt.Errorf("The profile needs the MAS instance ID. Please set MAXLOG_MAS_INSTANCE or instance=.")
+	}
+	value = strings.ReplaceAll(value, "{instance}", instance)
+	return strings.ReplaceAll(value, "{apptype}", apptype), nil
+}
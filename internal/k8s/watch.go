@@ -0,0 +1,167 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podWatch keeps track of the pods a Watch has reported as joined.
+type podWatch struct {
+	src    *Source                 // The source selecting the containers.
+	ctx    context.Context         // The context of the watch.
+	events chan source.TargetEvent // The channel receiving the events.
+	joined map[string]int32        // The restart count of every joined pod, by pod name.
+}
+
+// Watch reports the selected pods joining when they become ready and leaving when they terminate.
+//
+// Parameters:
+//
+//	ctx - The context. The channel is closed when it is cancelled.
+//
+// Returns:
+//
+//	<-chan source.TargetEvent - The channel receiving the events.
+//	error - Always nil. Errors of the watch are retried by the informer.
+//
+// Behavior:
+//   - Runs an informer on the pods matching the label and field selectors and the pod filter.
+//   - Pods that are ready when the watch starts join with the tail, later pods from the beginning.
+//   - A ready pod whose container has restarted joins again.
+//   - The events target joins first if the events option is set.
+func (src *Source) Watch(ctx context.Context) (<-chan source.TargetEvent, error) {
+	lw := cache.NewFilteredListWatchFromClient(src.clientset.CoreV1().RESTClient(), "pods", src.namespace, src.listOptions)
+	informer := cache.NewSharedIndexInformer(lw, &corev1.Pod{}, 0, cache.Indexers{})
+
+	pw := &podWatch{
+		src:    src,
+		ctx:    ctx,
+		events: make(chan source.TargetEvent),
+		joined: map[string]int32{},
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			pw.update(obj.(*corev1.Pod), isInInitialList)
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			pw.update(newObj.(*corev1.Pod), false)
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				pw.leave(pod)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if src.events {
+			pw.send(source.TargetEvent{Type: source.TargetJoined, Target: src.eventsTarget()})
+		}
+		informer.Run(ctx.Done())
+		close(pw.events)
+	}()
+	return pw.events, nil
+}
+
+// update reports a pod joining, rejoining or leaving depending on its state.
+//
+// Parameters:
+//
+//	pod     - The current state of the pod.
+//	initial - True if the pod was part of the initial list of the watch.
+func (pw *podWatch) update(pod *corev1.Pod, initial bool) {
+	if !pw.src.podFilter.match(pod.Name) {
+		return
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		pw.leave(pod)
+		return
+	}
+	if !podReady(pod) {
+		return
+	}
+
+	restarts := podRestarts(pod)
+	if last, found := pw.joined[pod.Name]; found && last == restarts {
+		return
+	}
+	pw.joined[pod.Name] = restarts
+	for _, target := range pw.src.podTargets(pod) {
+		target.FromStart = !initial
+		pw.send(source.TargetEvent{Type: source.TargetJoined, Target: target})
+	}
+}
+
+// leave reports a joined pod leaving.
+//
+// Parameters:
+//
+//	pod - The pod that has terminated or has been deleted.
+func (pw *podWatch) leave(pod *corev1.Pod) {
+	if _, found := pw.joined[pod.Name]; !found {
+		return
+	}
+	delete(pw.joined, pod.Name)
+	for _, target := range pw.src.podTargets(pod) {
+		pw.send(source.TargetEvent{Type: source.TargetLeft, Target: target})
+	}
+}
+
+// send passes an event to the channel unless the watch has been cancelled.
+//
+// Parameters:
+//
+//	event - The event to send.
+func (pw *podWatch) send(event source.TargetEvent) {
+	select {
+	case pw.events <- event:
+	case <-pw.ctx.Done():
+	}
+}
+
+// podReady checks whether the Ready condition of a pod is true.
+//
+// Parameters:
+//
+//	pod - The pod to check.
+//
+// Returns:
+//
+//	bool - true if the pod is ready, otherwise false.
+func podReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podRestarts sums the restart counts of all containers of a pod.
+//
+// Parameters:
+//
+//	pod - The pod.
+//
+// Returns:
+//
+//	int32 - The number of container restarts.
+func podRestarts(pod *corev1.Pod) int32 {
+	restarts := int32(0)
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
@@ -0,0 +1,613 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+)
+
+// pollInterval is the time between two checks for new data while following a file.
+const pollInterval = 500 * time.Millisecond
+
+func init() {
+	source.Register("file", NewSource)
+}
+
+// Source is a LogSource reading local log files, e.g. SystemOut.log or messages.log.
+type Source struct {
+	paths  []string // The files, directories or glob patterns to read.
+	tail   int      // The number of lines to tail from each file. Negative means all lines.
+	follow bool     // Whether to follow appended data.
+}
+
+// NewSource creates a Source for the file mode.
+//
+// Parameters:
+//
+//	opts - The options of the action.
+//
+// Returns:
+//
+//	source.LogSource - The created Source.
+//	error - An error if no file is set or the tail parameter is invalid.
+//
+// Behavior:
+//   - Uses MAXLOG_FILE if the options contain no file.
+//   - Accepts a comma-separated list of files, directories and glob patterns.
+//   - Accepts "all" as tail parameter to read the complete files.
+//   - Reads the complete files if the since or until option is set. The lines outside the time window are skipped by the pipeline.
+func NewSource(opts source.Options) (source.LogSource, error) {
+	files := opts.File
+	if files == "" {
+		files = os.Getenv("MAXLOG_FILE")
+	}
+	if files == "" {
+		return nil, fmt.Errorf("No log file is set. Please set MAXLOG_FILE environment variable or use the file option.")
+	}
+
+	tail := -1
+	if opts.Tail != "all" && opts.Since.IsZero() && opts.Until.IsZero() {
+		n, err := strconv.Atoi(opts.Tail)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing tail number: %w", err)
+		}
+		tail = n
+	}
+
+	src := &Source{tail: tail, follow: opts.Follow}
+	for _, path := range strings.Split(files, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			src.paths = append(src.paths, path)
+		}
+	}
+	return src, nil
+}
+
+// Targets lists a target for every log file and directory.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Target - One target per file or directory, using the path as name.
+//	error - An error if a path cannot be read or no file is found.
+//
+// Behavior:
+//   - Expands glob patterns.
+//   - Lists a directory as a single target if it contains *.log, *.txt or *.gz files.
+//     Its files are joined in time order by Open.
+func (src *Source) Targets(ctx context.Context) ([]source.Target, error) {
+	targets := []source.Target{}
+	for _, pattern := range src.paths {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			paths = []string{pattern}
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				targets = append(targets, source.Target{Name: path})
+				continue
+			}
+			files, err := listDir(path)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) > 0 {
+				targets = append(targets, source.Target{Name: path})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("No log files found in %s", strings.Join(src.paths, ", "))
+	}
+	return targets, nil
+}
+
+// Open opens a log file or the log files of a directory.
+//
+// Parameters:
+//
+//	ctx    - The context for the stream. Cancelling it stops following the file.
+//	target - The file or directory to read from.
+//
+// Returns:
+//
+//	io.ReadCloser - The lines of the file, starting at the tail.
+//	error - An error if the file cannot be opened.
+//
+// Behavior:
+//   - Opens a directory using openDir.
+//   - Decompresses gzip files transparently. They are never followed.
+//   - Follows appended data across rotation and truncation if the follow option is set.
+func (src *Source) Open(ctx context.Context, target source.Target) (io.ReadCloser, error) {
+	if info, err := os.Stat(target.Name); err == nil && info.IsDir() {
+		return src.openDir(ctx, target.Name)
+	}
+
+	f, err := os.Open(target.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(target.Name, ".gz") {
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading %s: %w", target.Name, err)
+		}
+		defer gz.Close()
+		return lastLines(gz, src.tail)
+	}
+
+	if err := seekTail(f, src.tail); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !src.follow {
+		return f, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(follow(ctx, target.Name, f, pw))
+	}()
+	return pr, nil
+}
+
+// openDir opens the log files of a directory as a single stream, e.g. messages.log and its rotated files.
+//
+// Parameters:
+//
+//	ctx - The context for the stream. Cancelling it stops following the newest file.
+//	dir - The directory.
+//
+// Returns:
+//
+//	io.ReadCloser - The lines of the files in modification-time order, starting at the tail of the joined stream.
+//	error - An error if a file cannot be opened or read.
+//
+// Behavior:
+//   - Takes the tail from the newest file and the older files before it, as far as needed.
+//   - Decompresses gzip files transparently.
+//   - Ends every file with a newline, so its last line is not joined with the first line of the next file.
+//   - Follows only the newest file if the follow option is set and it is not compressed.
+func (src *Source) openDir(ctx context.Context, dir string) (io.ReadCloser, error) {
+	files, err := listDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("No log files found in %s", dir)
+	}
+
+	joined := &joinedFiles{}
+	var newest *os.File
+	readers := []io.Reader{}
+	remaining := src.tail
+	for i := len(files) - 1; i >= 0 && (i == len(files)-1 || remaining != 0); i-- {
+		f, err := os.Open(files[i])
+		if err != nil {
+			joined.Close()
+			return nil, err
+		}
+		joined.files = append(joined.files, f)
+
+		var r io.Reader = f
+		count := 0
+		if strings.HasSuffix(files[i], ".gz") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				joined.Close()
+				return nil, fmt.Errorf("Error reading %s: %w", files[i], err)
+			}
+			lines, err := tailLines(gz, remaining)
+			gz.Close()
+			if err != nil {
+				joined.Close()
+				return nil, err
+			}
+			r, count = strings.NewReader(strings.Join(lines, "")), len(lines)
+		} else {
+			if err := seekTail(f, remaining); err != nil {
+				joined.Close()
+				return nil, err
+			}
+			if remaining > 0 {
+				if count, err = countLines(f); err != nil {
+					joined.Close()
+					return nil, err
+				}
+			}
+			if i == len(files)-1 {
+				newest = f
+			} else if !endsWithNewline(f) {
+				r = io.MultiReader(f, strings.NewReader("\n"))
+			}
+		}
+		readers = append([]io.Reader{r}, readers...)
+		if remaining > 0 {
+			remaining = max(0, remaining-count)
+		}
+	}
+
+	if !src.follow || newest == nil {
+		joined.Reader = io.MultiReader(readers...)
+		return joined, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := io.Copy(pw, io.MultiReader(readers[:len(readers)-1]...))
+		for _, f := range joined.files[1:] {
+			f.Close()
+		}
+		if err != nil {
+			newest.Close()
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(follow(ctx, files[len(files)-1], newest, pw))
+	}()
+	return pr, nil
+}
+
+// joinedFiles reads the joined files of a directory and closes them all.
+type joinedFiles struct {
+	io.Reader
+	files []*os.File // The opened files, newest first.
+}
+
+// Close closes all files.
+//
+// Returns:
+//
+//	error - Always nil.
+func (joined *joinedFiles) Close() error {
+	for _, f := range joined.files {
+		f.Close()
+	}
+	return nil
+}
+
+// Describe retrieves the log files and their sizes.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Property - The properties shown by the inspect action.
+//	error - An error if the files cannot be listed.
+func (src *Source) Describe(ctx context.Context) ([]source.Property, error) {
+	targets, err := src.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	props := []source.Property{{Key: "Files", Value: strconv.Itoa(len(targets))}}
+	for _, target := range targets {
+		files := []string{target.Name}
+		if info, err := os.Stat(target.Name); err == nil && info.IsDir() {
+			if files, err = listDir(target.Name); err != nil {
+				return nil, err
+			}
+			props = append(props, source.Property{Key: "Directory", Value: target.Name + " (" + strconv.Itoa(len(files)) + " files, oldest first)"})
+		}
+		for _, file := range files {
+			size := "?"
+			if info, err := os.Stat(file); err == nil {
+				size = strconv.FormatInt(info.Size(), 10) + " bytes"
+			}
+			props = append(props, source.Property{Key: "File", Value: file + " (" + size + ")"})
+		}
+	}
+	return props, nil
+}
+
+// listDir lists the log files of a directory.
+//
+// Parameters:
+//
+//	dir - The directory to list.
+//
+// Returns:
+//
+//	[]string - The paths of the *.log, *.txt and *.gz files, sorted by modification time.
+//	error - An error if the directory cannot be read.
+func listDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+	files := []file{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".txt") || strings.HasSuffix(name, ".gz")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file{filepath.Join(dir, name), info.ModTime()})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	return paths, nil
+}
+
+// seekTail positions a file at the start of its last lines.
+//
+// Parameters:
+//
+//	f    - The file to position.
+//	tail - The number of lines to keep. Negative means the whole file.
+//
+// Returns:
+//
+//	error - An error if the file cannot be read.
+//
+// Behavior:
+//   - Reads the file backwards in blocks and counts the newlines.
+//   - The newline terminating the last line does not count as a line break.
+func seekTail(f *os.File, tail int) error {
+	if tail < 0 {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if tail == 0 {
+		_, err = f.Seek(info.Size(), io.SeekStart)
+		return err
+	}
+
+	const blockSize = 64 * 1024
+	end := info.Size()
+	offset := end
+	count := 0
+	buf := make([]byte, blockSize)
+	for offset > 0 {
+		size := int64(blockSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		if _, err := f.ReadAt(buf[:size], offset); err != nil && err != io.EOF {
+			return err
+		}
+		for i := size - 1; i >= 0; i-- {
+			if buf[i] != '\n' || offset+i == end-1 {
+				continue
+			}
+			count++
+			if count == tail {
+				_, err := f.Seek(offset+i+1, io.SeekStart)
+				return err
+			}
+		}
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	return err
+}
+
+// lastLines reads a stream completely and keeps its last lines.
+//
+// Parameters:
+//
+//	r    - The stream to read.
+//	tail - The number of lines to keep. Negative means all lines.
+//
+// Returns:
+//
+//	io.ReadCloser - A reader providing the kept lines.
+//	error - An error if the stream cannot be read.
+func lastLines(r io.Reader, tail int) (io.ReadCloser, error) {
+	lines, err := tailLines(r, tail)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewBufferString(strings.Join(lines, ""))), nil
+}
+
+// tailLines reads a stream completely and keeps its last lines.
+//
+// Parameters:
+//
+//	r    - The stream to read.
+//	tail - The number of lines to keep. Negative means all lines.
+//
+// Returns:
+//
+//	[]string - The kept lines, each ending with a newline.
+//	error - An error if the stream cannot be read.
+func tailLines(r io.Reader, tail int) ([]string, error) {
+	lines := []string{}
+	buffer := bufio.NewReader(r)
+	for {
+		line, err := buffer.ReadString('\n')
+		if line != "" {
+			lines = append(lines, line)
+			if tail >= 0 && len(lines) > tail {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		lines[n-1] += "\n"
+	}
+	return lines, nil
+}
+
+// countLines counts the lines from the current position of a file to its end.
+//
+// Parameters:
+//
+//	f - The file. Its position is kept.
+//
+// Returns:
+//
+//	int - The number of lines, including a last line without a newline.
+//	error - An error if the file cannot be read.
+func countLines(f *os.File) (int, error) {
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	last := byte('\n')
+	buf := make([]byte, 64*1024)
+	for pos := offset; pos < info.Size(); {
+		n, err := f.ReadAt(buf, pos)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if n > 0 {
+			last = buf[n-1]
+		}
+		pos += int64(n)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		count++
+	}
+	return count, nil
+}
+
+// endsWithNewline checks whether the last byte of a file is a newline.
+//
+// Parameters:
+//
+//	f - The file.
+//
+// Returns:
+//
+//	bool - true if the file ends with a newline or is empty, otherwise false.
+func endsWithNewline(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return true
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return true
+	}
+	return last[0] == '\n'
+}
+
+// follow copies a file and the data appended to it, like tail -F.
+//
+// Parameters:
+//
+//	ctx  - The context. Following stops when it is cancelled.
+//	path - The path of the file, used to detect rotation.
+//	f    - The opened file, positioned at the first byte to copy.
+//	w    - The writer receiving the data.
+//
+// Returns:
+//
+//	error - An error if reading or writing fails, nil when the context is cancelled.
+//
+// Behavior:
+//   - Polls for new data when the end of the file is reached.
+//   - Reopens the path from the beginning when the file has been replaced by rotation.
+//   - Starts from the beginning when the file has been truncated.
+//   - Reports a rotation or truncation with a line starting with source.NoticeMark, which the pipeline shows as a marker.
+//   - Keeps waiting while the path does not exist during rotation.
+func follow(ctx context.Context, path string, f *os.File, w io.Writer) error {
+	defer func() { f.Close() }()
+
+	for {
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+
+		current, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if !os.SameFile(current, info) {
+			// Copy what has been written to the old file before reopening the path.
+			if _, err := io.Copy(w, f); err != nil {
+				return err
+			}
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			if !endsWithNewline(f) {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+			f.Close()
+			f = next
+			fmt.Fprintln(w, source.NoticeMark+path+" has been rotated")
+			continue
+		}
+
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			fmt.Fprintln(w, source.NoticeMark+path+" has been truncated")
+		}
+	}
+}
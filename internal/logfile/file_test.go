@@ -0,0 +1,104 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSeekTail(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		tail    int
+		want    string
+	}{
+		{"all lines", "a\nb\nc\n", -1, "a\nb\nc\n"},
+		{"no lines", "a\nb\nc\n", 0, ""},
+		{"last line", "a\nb\nc\n", 1, "c\n"},
+		{"last two lines", "a\nb\nc\n", 2, "b\nc\n"},
+		{"more than the file", "a\nb\n", 5, "a\nb\n"},
+		{"without trailing newline", "a\nb\nc", 2, "b\nc"},
+		{"empty file", "", 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.log")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if err := seekTail(f, tt.tail); err != nil {
+				t.Fatalf("seekTail() error = %v", err)
+			}
+			got, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("seekTail() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenDir(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, content string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) == ".gz" {
+			gz := gzip.NewWriter(f)
+			gz.Write([]byte(content))
+			gz.Close()
+		} else {
+			f.WriteString(content)
+		}
+		f.Close()
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("messages.log", "new 1\nnew 2\n", 0)
+	write("messages_1.log", "old 1\nold 2", 2*time.Minute)
+	write("messages_2.log.gz", "gz 1\ngz 2\n", time.Minute)
+
+	tests := []struct {
+		tail int
+		want string
+	}{
+		{-1, "old 1\nold 2\ngz 1\ngz 2\nnew 1\nnew 2\n"},
+		{0, ""},
+		{1, "new 2\n"},
+		{3, "gz 2\nnew 1\nnew 2\n"},
+		{5, "old 2\ngz 1\ngz 2\nnew 1\nnew 2\n"},
+	}
+	for _, tt := range tests {
+		src := &Source{tail: tt.tail}
+		r, err := src.openDir(context.Background(), dir)
+		if err != nil {
+			t.Fatalf("openDir() error = %v", err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("openDir() with tail %d = %q, want %q", tt.tail, got, tt.want)
+		}
+	}
+}
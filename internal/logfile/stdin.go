@@ -0,0 +1,73 @@
+package logfile
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+)
+
+func init() {
+	source.Register("stdin", NewStdinSource)
+}
+
+// StdinSource is a LogSource reading the standard input, e.g. the output of oc logs.
+type StdinSource struct{}
+
+// NewStdinSource creates a StdinSource.
+//
+// Parameters:
+//
+//	opts - The options of the action. The tail and follow options are ignored. The since and until options are applied by the pipeline.
+//
+// Returns:
+//
+//	source.LogSource - The created StdinSource.
+//	error - Always nil.
+func NewStdinSource(opts source.Options) (source.LogSource, error) {
+	return &StdinSource{}, nil
+}
+
+// Targets lists the standard input as the only target.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Target - A single target named stdin.
+//	error - Always nil.
+func (src *StdinSource) Targets(ctx context.Context) ([]source.Target, error) {
+	return []source.Target{{Name: "stdin"}}, nil
+}
+
+// Open returns the standard input.
+//
+// Parameters:
+//
+//	ctx    - The context for the stream.
+//	target - The target returned by Targets.
+//
+// Returns:
+//
+//	io.ReadCloser - The standard input. Closing it does not close os.Stdin.
+//	error - Always nil.
+func (src *StdinSource) Open(ctx context.Context, target source.Target) (io.ReadCloser, error) {
+	return io.NopCloser(os.Stdin), nil
+}
+
+// Describe retrieves the input of the source.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Property - The properties shown by the inspect action.
+//	error - Always nil.
+func (src *StdinSource) Describe(ctx context.Context) ([]source.Property, error) {
+	return []source.Property{{Key: "Input", Value: "stdin"}}, nil
+}
@@ -2,140 +2,595 @@ package moby
 
 import (
 	"context"
-	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"path"
+	"regexp"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/maxtoolbox/maxlog/internal/cmdln"
+	"github.com/maxtoolbox/maxlog/internal/record"
+	"github.com/maxtoolbox/maxlog/internal/source"
 
 	// Never mind. We use the Moby client for Podman. We can swap it out later.
 	// Unfortunately, I'm having some problems with Windows right now.
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/filters"
 	"github.com/moby/moby/client"
 )
 
-// GetLog retrieves and processes logs for a specific container.
+func init() {
+	source.Register("pod", NewSource)
+}
+
+// Source is a LogSource reading the logs of Podman or Docker containers.
+type Source struct {
+	name     string         // The comma-separated container names, glob patterns or /regex/ patterns.
+	patterns []pattern      // The compiled container names and patterns.
+	labels   []string       // The label filters of the containers, e.g. com.docker.compose.project=mas.
+	tail     string         // The number of lines to tail from the logs.
+	follow   bool           // Whether to follow the log stream.
+	since    time.Time      // The time the logs are requested from, zero for the tail.
+	until    time.Time      // The time the logs are requested up to, zero for no limit.
+	cli      *client.Client // The Moby client.
+}
+
+// pattern is a single container name or pattern of MAXLOG_CONTAINER.
+type pattern struct {
+	text  string         // The name or pattern as given by the user.
+	regex *regexp.Regexp // The compiled /regex/ pattern, nil otherwise.
+	glob  bool           // Whether the text is a glob pattern.
+}
+
+// NewSource creates a Source for the Podman mode.
 //
 // Parameters:
 //
-//	name - A string representing the name of the container whose logs are to be retrieved.
-//	tail - A string specifying the number of lines to tail from the logs.
-//	follow - A boolean indicating whether to follow the log stream.
-//	tag - A string representing a tag to be applied to the log lines.
+//	opts - The options of the action.
+//
+// Returns:
+//
+//	source.LogSource - The created Source.
+//	error - An error if a container pattern is invalid or no Podman or Docker endpoint answers.
 //
 // Behavior:
-//   - Resolves the container ID using the GetCID function based on the provided container name.
-//   - Passes the container ID and tail parameter to the getContainerLogs function for log retrieval and processing.
-func GetLog(name, tail string, follow bool, tag string) {
-	getContainerLogs(GetCID(name), tail, follow, tag)
+//   - Uses the container option or MAXLOG_CONTAINER, and the label option or MAXLOG_CONTAINER_LABEL.
+//   - Accepts an unset container, so inspect can list the candidate containers.
+//   - Connects to Podman or Docker using newClient.
+func NewSource(opts source.Options) (source.LogSource, error) {
+	name := opts.Container
+	if name == "" {
+		name = os.Getenv("MAXLOG_CONTAINER")
+	}
+	label := opts.Label
+	if label == "" {
+		label = os.Getenv("MAXLOG_CONTAINER_LABEL")
+	}
+
+	src := &Source{
+		name:   name,
+		tail:   opts.Tail,
+		follow: opts.Follow,
+		since:  opts.Since,
+		until:  opts.Until,
+	}
+	for _, text := range strings.Split(name, ",") {
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		p := pattern{text: text}
+		if len(text) > 1 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/") {
+			regex, err := regexp.Compile(text[1 : len(text)-1])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid container pattern '%s': %w", text, err)
+			}
+			p.regex = regex
+		} else if strings.ContainsAny(text, "*?[") {
+			if _, err := path.Match(text, ""); err != nil {
+				return nil, fmt.Errorf("Invalid container pattern '%s': %w", text, err)
+			}
+			p.glob = true
+		}
+		src.patterns = append(src.patterns, p)
+	}
+	for _, value := range strings.Split(label, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			src.labels = append(src.labels, value)
+		}
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return nil, fmt.Errorf("Error creating Moby client: %w", err)
+	}
+	src.cli = cli
+	return src, nil
 }
 
-// GetCID retrieves the container ID for a given container name.
+// GetContainers retrieves the containers matching the configured names, patterns and labels.
 //
 // Parameters:
 //
-//	name - A string representing the name of the container to search for.
+//	ctx - The context for the request.
 //
 // Returns:
 //
-//	string - The ID of the container matching the given name.
+//	[]container.Summary - The matching containers.
+//	error - An error listing the candidate containers if the name is not set, ambiguous or matches no container.
+func (src *Source) GetContainers(ctx context.Context) ([]container.Summary, error) {
+	selected, candidates, err := src.candidates(ctx)
+	if err != nil || len(selected) > 0 {
+		return selected, err
+	}
+
+	names := []string{}
+	for _, candidate := range candidates {
+		names = append(names, containerName(candidate))
+	}
+	switch {
+	case src.name == "" && len(src.labels) == 0:
+		return nil, fmt.Errorf("Container name is not set. Please set MAXLOG_CONTAINER environment variable to one of: %s", strings.Join(names, ", "))
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("The search for a container '%s' has not yielded any results.", src.name)
+	}
+	return nil, fmt.Errorf("The container name '%s' is ambiguous. Please set MAXLOG_CONTAINER to one of: %s", src.name, strings.Join(names, ", "))
+}
+
+// candidates finds the containers matching the configured names, patterns and labels.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]container.Summary - The selected containers, empty if the name is not set or ambiguous.
+//	[]container.Summary - The candidates: the selected containers, the ambiguous matches, or all running containers if the name is not set.
+//	error - An error if the containers cannot be listed.
 //
 // Behavior:
-//   - Creates a Moby client to interact with the container runtime.
-//   - Retrieves a list of all containers using the Moby client.
-//   - Iterates through the list of containers to find one whose name matches the provided name.
-//   - Returns the container ID if a match is found.
-//   - Logs a fatal error and terminates the program if no matching container is found.
-func GetCID(name string) string {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+//   - Passes the labels as filters to the container engine. With labels only, all matching containers are selected.
+//   - Selects all containers matching a glob or /regex/ pattern.
+//   - Selects the container whose name equals a plain name, otherwise the only container whose name
+//     contains it or whose ID starts with it.
+func (src *Source) candidates(ctx context.Context) ([]container.Summary, []container.Summary, error) {
+	options := container.ListOptions{Filters: filters.NewArgs()}
+	for _, label := range src.labels {
+		options.Filters.Add("label", label)
+	}
+	containers, err := src.cli.ContainerList(ctx, options)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
+	}
+	if len(src.patterns) == 0 {
+		if len(src.labels) > 0 {
+			return containers, containers, nil
+		}
+		return nil, containers, nil
 	}
 
-	containers, err := cli.ContainerList(context.Background(), container.ListOptions{})
-	if err != nil {
-		panic(err)
+	selected := []container.Summary{}
+	add := func(summary container.Summary) {
+		if !slices.ContainsFunc(selected, func(s container.Summary) bool { return s.ID == summary.ID }) {
+			selected = append(selected, summary)
+		}
+	}
+	for _, p := range src.patterns {
+		if p.regex != nil || p.glob {
+			for _, candidate := range containers {
+				if p.match(containerName(candidate)) {
+					add(candidate)
+				}
+			}
+			continue
+		}
+		matches := matchName(containers, p.text)
+		if len(matches) != 1 {
+			return nil, matches, nil
+		}
+		add(matches[0])
+	}
+	return selected, selected, nil
+}
+
+// match checks a container name against a glob or /regex/ pattern.
+//
+// Parameters:
+//
+//	name - The name of the container.
+//
+// Returns:
+//
+//	bool - true if the pattern matches, otherwise false.
+func (p pattern) match(name string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(name)
 	}
+	matched, _ := path.Match(p.text, name)
+	return matched
+}
 
-	for _, container := range containers {
-		if slices.Contains(container.Names, "/"+name) {
-			return container.ID
+// matchName finds the containers matching a plain container name.
+//
+// Parameters:
+//
+//	containers - The listed containers.
+//	name       - The container name, a part of it or the beginning of the ID.
+//
+// Returns:
+//
+//	[]container.Summary - The container with exactly that name, otherwise all containers whose name contains it or whose ID starts with it.
+func matchName(containers []container.Summary, name string) []container.Summary {
+	matches := []container.Summary{}
+	for _, candidate := range containers {
+		if slices.Contains(candidate.Names, "/"+name) {
+			return []container.Summary{candidate}
+		}
+		if strings.Contains(containerName(candidate), name) || strings.HasPrefix(candidate.ID, name) {
+			matches = append(matches, candidate)
 		}
 	}
+	return matches
+}
 
-	log.Fatal("The search for a container has not yielded any results.")
-	return ""
+// containerName returns the name of a listed container.
+//
+// Parameters:
+//
+//	summary - The listed container.
+//
+// Returns:
+//
+//	string - The first name of the container without the leading slash.
+func containerName(summary container.Summary) string {
+	if len(summary.Names) == 0 {
+		return summary.ID[:min(12, len(summary.ID))]
+	}
+	return strings.TrimPrefix(summary.Names[0], "/")
 }
 
-// getContainerLogs retrieves and processes logs for a specific container.
+// Targets lists the matching containers.
 //
 // Parameters:
 //
-//	cid - A string representing the container ID whose logs are to be retrieved.
-//	tail - A string specifying the number of lines to tail from the logs.
-//	follow - A boolean indicating whether to follow the log stream.
-//	tag - A string representing a tag to be applied to the log lines.
+//	ctx - The context for the request.
 //
-// Behavior:
-//   - Creates a Moby client to interact with the container runtime.
-//   - Configures log options, including stdout, stderr, timestamps, and tailing.
-//   - Retrieves the container logs using the specified options.
-//   - Processes the log stream by reading headers and data chunks.
-//   - Extracts and formats log lines using the SetLabels function.
-//   - Handles errors during log retrieval and processing, terminating the program if necessary.
-func getContainerLogs(cid, tail string, follow bool, tag string) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+// Returns:
+//
+//	[]source.Target - A target for every matching container.
+//	error - An error if no container matches or the name is ambiguous.
+func (src *Source) Targets(ctx context.Context) ([]source.Target, error) {
+	containers, err := src.GetContainers(ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	targets := []source.Target{}
+	for _, summary := range containers {
+		targets = append(targets, source.Target{Name: containerName(summary), ID: summary.ID, Timestamped: true})
+	}
+	return targets, nil
+}
+
+// DefaultPrefix prefixes the lines with the container name if several containers are streamed.
+//
+// Parameters:
+//
+//	targets - The targets to stream.
+//
+// Returns:
+//
+//	string - record.PrefixName for several targets, otherwise an empty string.
+func (src *Source) DefaultPrefix(targets []source.Target) string {
+	if len(targets) > 1 {
+		return record.PrefixName
+	}
+	return ""
+}
 
+// Open opens the log stream of a container.
+//
+// Parameters:
+//
+//	ctx    - The context for the stream.
+//	target - The container to read from.
+//
+// Returns:
+//
+//	io.ReadCloser - The demultiplexed log stream of the container.
+//	error - An error if the logs cannot be retrieved.
+//
+// Behavior:
+//   - Configures log options, including stdout, stderr, timestamps, and tailing.
+//   - Requests the log since the target's time if it is set, otherwise since the since option.
+//   - Requests the log up to the until option if it is set.
+//   - Requests the whole log instead of the tail if the target starts from the beginning or a time is set.
+//   - Inspects the container to find out whether it has a TTY, in which case the stream is not multiplexed.
+//   - Starts a goroutine demultiplexing the stream into plain log lines using demux.
+func (src *Source) Open(ctx context.Context, target source.Target) (io.ReadCloser, error) {
 	options := container.LogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
 		Since:      "",
 		Until:      "",
 		Timestamps: true,
-		Follow:     follow,
-		Tail:       tail,
+		Follow:     src.follow,
+		Tail:       src.tail,
 		Details:    false,
 	}
+	since := target.Since
+	if since.IsZero() {
+		since = src.since
+	}
+	if !since.IsZero() {
+		options.Since = timestamp(since)
+	}
+	if !src.until.IsZero() {
+		options.Until = timestamp(src.until)
+	}
+	if target.FromStart || options.Since != "" || options.Until != "" {
+		options.Tail = "all"
+	}
 
-	reader, err := cli.ContainerLogs(context.Background(), cid, options)
+	info, err := src.cli.ContainerInspect(ctx, target.ID)
 	if err != nil {
-		log.Fatal(err)
-		os.Exit(1)
+		return nil, err
 	}
-	defer reader.Close()
+	tty := info.Config != nil && info.Config.Tty
 
-	hdr := make([]byte, 8)
-	for {
-		_, err := reader.Read(hdr)
-		if err != nil {
-			if err == io.EOF {
-				return
-			}
+	reader, err := src.cli.ContainerLogs(ctx, target.ID, options)
+	if err != nil {
+		return nil, err
+	}
 
-			log.Fatal(err)
+	pr, pw := io.Pipe()
+	go func() {
+		defer reader.Close()
+		pw.CloseWithError(demux(reader, pw, tty))
+	}()
+	return pr, nil
+}
+
+// timestamp formats a time for the log options of the Moby API.
+//
+// Parameters:
+//
+//	t - The time.
+//
+// Returns:
+//
+//	string - The Unix time with nanoseconds, e.g. 1760623501.000000000.
+func timestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// Describe retrieves the details of the selected container.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	[]source.Property - The properties shown by the inspect action.
+//	error - An error if the containers cannot be listed or inspected.
+//
+// Behavior:
+//   - Shows the image, status, start time, restart count, health, ports, mounts and log driver using ContainerInspect.
+//     Parts missing from the response, e.g. of an older Podman, are shown as unknown.
+//   - Shows only the number of selected containers if several match. They are listed by Details.
+//   - Shows only the number of candidates if the container name is not set or ambiguous. They are listed by Details.
+func (src *Source) Describe(ctx context.Context) ([]source.Property, error) {
+	selected, candidates, err := src.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) != 1 {
+		name := src.name
+		if name == "" {
+			name = "not set, see MAXLOG_CONTAINER"
+		}
+		props := []source.Property{
+			{Key: "Host", Value: src.cli.DaemonHost()},
+			{Key: "Container", Value: name},
 		}
+		if len(src.labels) > 0 {
+			props = append(props, source.Property{Key: "Labels", Value: strings.Join(src.labels, ", ")})
+		}
+		key := "Candidates"
+		if len(selected) > 1 {
+			key = "Selected"
+		}
+		return append(props, source.Property{Key: key, Value: strconv.Itoa(len(candidates))}), nil
+	}
 
-		count := binary.BigEndian.Uint32(hdr[4:])
-		dat := make([]byte, count)
-		_, err = reader.Read(dat)
-		if err != nil && err != io.EOF {
-			log.Fatal(err)
+	cid := selected[0].ID
+	info, err := src.cli.ContainerInspect(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	if info.ContainerJSONBase == nil {
+		info.ContainerJSONBase = &container.ContainerJSONBase{Name: containerName(selected[0])}
+	}
+	image := selected[0].Image
+	if info.Config != nil {
+		image = info.Config.Image
+	}
+	started, health := "unknown", describeHealth(nil)
+	if info.State != nil {
+		started, health = describeTime(info.State.StartedAt), describeHealth(info.State.Health)
+	}
+	props := []source.Property{
+		{Key: "Host", Value: src.cli.DaemonHost()},
+		{Key: "Container", Value: strings.TrimPrefix(info.Name, "/")},
+		{Key: "CID", Value: cid},
+		{Key: "Image", Value: image},
+		{Key: "Status", Value: describeStatus(info.State)},
+		{Key: "Started", Value: started},
+		{Key: "Restarts", Value: strconv.Itoa(info.RestartCount)},
+		{Key: "Health", Value: health},
+		{Key: "Ports", Value: describePorts(info.NetworkSettings)},
+	}
+	for _, mount := range info.Mounts {
+		mode := "rw"
+		if !mount.RW {
+			mode = "ro"
 		}
+		props = append(props, source.Property{Key: "Mount", Value: fmt.Sprintf("%s -> %s (%s)", mount.Source, mount.Destination, mode)})
+	}
+	if info.HostConfig != nil {
+		props = append(props, source.Property{Key: "Log Driver", Value: info.HostConfig.LogConfig.Type})
+	}
+	return props, nil
+}
 
-		// time, line, found
-		_, line, found := strings.Cut(string(dat), " ")
-		if found {
-			text := cmdln.SetLabels(line, tag)
-			if text != "" {
-				fmt.Print(text)
-			}
+// ContainerInfo describes a candidate container for the inspect action.
+type ContainerInfo struct {
+	Name   string `json:"name"`
+	ID     string `json:"id"`
+	Image  string `json:"image"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Health string `json:"health,omitempty"`
+}
+
+// Details retrieves a table of the candidate containers.
+//
+// Parameters:
+//
+//	ctx - The context for the request.
+//
+// Returns:
+//
+//	source.Details - One row per candidate and the ContainerInfo items for the JSON output.
+//	error - An error if the containers cannot be listed.
+//
+// Behavior:
+//   - Lists the selected containers, all running containers if the container name is not set,
+//     or the matching ones if it is ambiguous.
+func (src *Source) Details(ctx context.Context) (source.Details, error) {
+	_, candidates, err := src.candidates(ctx)
+	if err != nil {
+		return source.Details{}, err
+	}
+	infos := []ContainerInfo{}
+	details := source.Details{Columns: []string{"NAME", "ID", "IMAGE", "STATE", "STATUS", "HEALTH"}}
+	for _, candidate := range candidates {
+		info := ContainerInfo{
+			Name:   containerName(candidate),
+			ID:     candidate.ID[:min(12, len(candidate.ID))],
+			Image:  candidate.Image,
+			State:  string(candidate.State),
+			Status: candidate.Status,
+		}
+		if candidate.Health != nil {
+			info.Health = string(candidate.Health.Status)
+		}
+		infos = append(infos, info)
+		health := info.Health
+		if health == "" {
+			health = "-"
+		}
+		details.Rows = append(details.Rows, []string{info.Name, info.ID, info.Image, info.State, info.Status, health})
+	}
+	details.Items = infos
+	return details, nil
+}
+
+// describeStatus formats the state of a container.
+//
+// Parameters:
+//
+//	state - The state of the container.
+//
+// Returns:
+//
+//	string - The status, e.g. running, or exited (137, OOM killed).
+func describeStatus(state *container.State) string {
+	if state == nil {
+		return "unknown"
+	}
+	status := string(state.Status)
+	if state.Running {
+		return status
+	}
+	details := []string{strconv.Itoa(state.ExitCode)}
+	if state.OOMKilled {
+		details = append(details, "OOM killed")
+	}
+	if state.Error != "" {
+		details = append(details, state.Error)
+	}
+	return status + " (" + strings.Join(details, ", ") + ")"
+}
+
+// describeTime formats a timestamp of the Moby API in local time.
+//
+// Parameters:
+//
+//	value - The timestamp in RFC3339 format.
+//
+// Returns:
+//
+//	string - The local time, or the value itself if it cannot be parsed.
+func describeTime(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.IsZero() {
+		return value
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// describeHealth formats the health check state of a container.
+//
+// Parameters:
+//
+//	health - The health of the container, nil without a health check.
+//
+// Returns:
+//
+//	string - The status, the failing streak and the output of the last check.
+func describeHealth(health *container.Health) string {
+	if health == nil {
+		return "no health check"
+	}
+	text := string(health.Status)
+	if health.FailingStreak > 0 {
+		text += fmt.Sprintf(", %d failures in a row", health.FailingStreak)
+	}
+	if n := len(health.Log); n > 0 {
+		if output := strings.TrimSpace(health.Log[n-1].Output); output != "" {
+			text += ": " + output
+		}
+	}
+	return text
+}
+
+// describePorts formats the published ports of a container.
+//
+// Parameters:
+//
+//	settings - The network settings of the container.
+//
+// Returns:
+//
+//	string - The ports, e.g. 9080/tcp -> 0.0.0.0:9080, or - if none are exposed.
+func describePorts(settings *container.NetworkSettings) string {
+	if settings == nil || len(settings.Ports) == 0 {
+		return "-"
+	}
+	ports := []string{}
+	for port, bindings := range settings.Ports {
+		if len(bindings) == 0 {
+			ports = append(ports, string(port))
+		}
+		for _, binding := range bindings {
+			ports = append(ports, fmt.Sprintf("%s -> %s:%s", port, binding.HostIP, binding.HostPort))
 		}
 	}
+	sort.Strings(ports)
+	return strings.Join(ports, ", ")
 }
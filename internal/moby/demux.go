@@ -0,0 +1,126 @@
+package moby
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+)
+
+const (
+	// streamStdout, streamStderr and streamSystemErr identify the frames of a multiplexed Moby stream.
+	streamStdout    = 1
+	streamStderr    = 2
+	streamSystemErr = 3
+)
+
+// demux copies the log lines of a Moby log stream.
+//
+// Parameters:
+//
+//	reader - The log stream.
+//	w      - The writer receiving the log lines.
+//	tty    - Whether the container has a TTY. Its stream is not multiplexed and is copied as is.
+//
+// Returns:
+//
+//	error - An error if reading or writing fails or the daemon reports an error, nil on EOF.
+//
+// Behavior:
+//   - Reads every frame completely, even if the header or payload arrives in several reads.
+//   - Collects the lines of stdout and stderr separately, so lines split across frames are joined.
+//   - Marks stderr lines with source.StderrMark, so the line itself is left unchanged.
+//   - Writes an incomplete last line at the end of the stream.
+func demux(reader io.Reader, w io.Writer, tty bool) error {
+	if tty {
+		_, err := io.Copy(w, reader)
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	hdr := make([]byte, 8)
+	payload := []byte{}
+	for {
+		if _, err := io.ReadFull(reader, hdr); err != nil {
+			if err == io.EOF {
+				return errors.Join(flushLines(&stdout, w, false, true), flushLines(&stderr, w, true, true))
+			}
+			return err
+		}
+
+		size := int(binary.BigEndian.Uint32(hdr[4:]))
+		if cap(payload) < size {
+			payload = make([]byte, size)
+		}
+		payload = payload[:size]
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return err
+		}
+
+		switch hdr[0] {
+		case streamStdout:
+			stdout.Write(payload)
+			if err := flushLines(&stdout, w, false, false); err != nil {
+				return err
+			}
+		case streamStderr:
+			stderr.Write(payload)
+			if err := flushLines(&stderr, w, true, false); err != nil {
+				return err
+			}
+		case streamSystemErr:
+			return fmt.Errorf("Error from the container engine: %s", strings.TrimSpace(string(payload)))
+		}
+	}
+}
+
+// flushLines writes the complete lines collected for a stream.
+//
+// Parameters:
+//
+//	buf      - The collected data of the stream. The written lines are removed.
+//	w        - The writer receiving the lines.
+//	isStderr - Whether the lines are marked as stderr.
+//	final    - Whether to write an incomplete last line as well, at the end of the stream.
+//
+// Returns:
+//
+//	error - An error if writing fails.
+func flushLines(buf *bytes.Buffer, w io.Writer, isStderr bool, final bool) error {
+	for {
+		line, err := buf.ReadString('\n')
+		if err != nil {
+			if final && line != "" {
+				return writeLine(w, line+"\n", isStderr)
+			}
+			buf.WriteString(line)
+			return nil
+		}
+		if err := writeLine(w, line, isStderr); err != nil {
+			return err
+		}
+	}
+}
+
+// writeLine writes a line, marking stderr lines with source.StderrMark in front of them.
+//
+// Parameters:
+//
+//	w        - The writer receiving the line.
+//	line     - The line including the newline.
+//	isStderr - Whether to mark the line as stderr.
+//
+// Returns:
+//
+//	error - An error if writing fails.
+func writeLine(w io.Writer, line string, isStderr bool) error {
+	if isStderr {
+		line = source.StderrMark + line
+	}
+	_, err := io.WriteString(w, line)
+	return err
+}
@@ -0,0 +1,107 @@
+package moby
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+)
+
+// frame builds a frame of a multiplexed Moby stream.
+func frame(stream byte, payload string) string {
+	hdr := make([]byte, 8)
+	hdr[0] = stream
+	binary.BigEndian.PutUint32(hdr[4:], uint32(len(payload)))
+	return string(hdr) + payload
+}
+
+func TestDemux(t *testing.T) {
+	const stamp = "2025-10-16T14:05:00.000000000Z "
+	tests := []struct {
+		name    string
+		stream  string
+		tty     bool
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "stdout lines",
+			stream: frame(streamStdout, stamp+"a\n"+stamp+"b\n"),
+			want:   stamp + "a\n" + stamp + "b\n",
+		},
+		{
+			name:   "frames splitting a line",
+			stream: frame(streamStdout, stamp+"a long") + frame(streamStdout, " line\n"),
+			want:   stamp + "a long line\n",
+		},
+		{
+			name:   "stderr",
+			stream: frame(streamStderr, stamp+"[ERROR] failed\n"),
+			want:   source.StderrMark + stamp + "[ERROR] failed\n",
+		},
+		{
+			name: "stderr between the parts of a stdout line",
+			stream: frame(streamStdout, stamp+"out") + frame(streamStderr, stamp+"err\n") +
+				frame(streamStdout, " continued\n"),
+			want: source.StderrMark + stamp + "err\n" + stamp + "out continued\n",
+		},
+		{
+			name:   "incomplete last line",
+			stream: frame(streamStdout, stamp+"a\n"+stamp+"b"),
+			want:   stamp + "a\n" + stamp + "b\n",
+		},
+		{
+			name:   "empty stream",
+			stream: "",
+			want:   "",
+		},
+		{
+			name:    "error from the engine",
+			stream:  frame(streamStdout, stamp+"a\n") + frame(streamSystemErr, "no such container\n"),
+			want:    stamp + "a\n",
+			wantErr: "Error from the container engine: no such container",
+		},
+		{
+			name:    "truncated frame",
+			stream:  frame(streamStdout, stamp+"a\n")[:12],
+			wantErr: "unexpected EOF",
+		},
+		{
+			name:   "tty",
+			stream: stamp + "a\n" + stamp + "b",
+			tty:    true,
+			want:   stamp + "a\n" + stamp + "b",
+		},
+	}
+	for _, tt := range tests {
+		for _, short := range []bool{false, true} {
+			name := tt.name
+			if short {
+				name += " with short reads"
+			}
+			t.Run(name, func(t *testing.T) {
+				reader := strings.NewReader(tt.stream)
+				var out bytes.Buffer
+				var err error
+				if short {
+					err = demux(iotest.OneByteReader(reader), &out, tt.tty)
+				} else {
+					err = demux(reader, &out, tt.tty)
+				}
+				if tt.wantErr != "" {
+					if err == nil || err.Error() != tt.wantErr {
+						t.Fatalf("demux() error = %v, want %q", err, tt.wantErr)
+					}
+				} else if err != nil {
+					t.Fatalf("demux() error = %v", err)
+				}
+				if out.String() != tt.want {
+					t.Errorf("demux() = %q, want %q", out.String(), tt.want)
+				}
+			})
+		}
+	}
+}
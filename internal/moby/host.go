@@ -0,0 +1,122 @@
+package moby
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/client"
+)
+
+// pingTimeout is the time a discovered endpoint has to answer.
+const pingTimeout = 3 * time.Second
+
+// endpoints lists the sockets of Podman and Docker in the order they are tried.
+//
+// Returns:
+//
+//	[]string - The endpoints, e.g. unix:///run/user/1000/podman/podman.sock.
+//
+// Behavior:
+//   - Starts with the rootless Podman socket under XDG_RUNTIME_DIR, or /run/user/<uid> if it is not set.
+//   - Continues with the rootful Podman socket, the Podman machine socket on macOS and the Docker sockets.
+func endpoints() []string {
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" && os.Getuid() >= 0 {
+		runtimeDir = "/run/user/" + strconv.Itoa(os.Getuid())
+	}
+
+	sockets := []string{}
+	if runtimeDir != "" {
+		sockets = append(sockets, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	sockets = append(sockets, "/run/podman/podman.sock")
+	if home, err := os.UserHomeDir(); err == nil {
+		sockets = append(sockets,
+			filepath.Join(home, ".local", "share", "containers", "podman", "machine", "podman.sock"),
+			filepath.Join(home, ".docker", "run", "docker.sock"),
+		)
+	}
+	sockets = append(sockets, "/var/run/docker.sock")
+
+	hosts := make([]string, 0, len(sockets))
+	for _, socket := range sockets {
+		hosts = append(hosts, "unix://"+socket)
+	}
+	return hosts
+}
+
+// newClient creates a Moby client connected to Podman or Docker.
+//
+// Returns:
+//
+//	*client.Client - The connected client.
+//	error - An error listing the endpoints tried if none answers.
+//
+// Behavior:
+//   - Uses MAXLOG_HOST if set, e.g. unix:///run/podman/podman.sock or tcp://localhost:2375.
+//   - Uses DOCKER_HOST and the other Docker environment variables if DOCKER_HOST is set.
+//   - Otherwise tries the sockets of endpoints one after another and uses the first one answering a ping.
+//   - Falls back to the platform's default endpoint if no socket exists, e.g. the named pipe on Windows.
+func newClient() (*client.Client, error) {
+	if host := os.Getenv("MAXLOG_HOST"); host != "" {
+		return connect("MAXLOG_HOST", client.WithHost(host))
+	}
+	if os.Getenv("DOCKER_HOST") != "" {
+		return connect("DOCKER_HOST", client.FromEnv)
+	}
+
+	hosts := endpoints()
+	tried := []string{}
+	for _, host := range hosts {
+		socket := strings.TrimPrefix(host, "unix://")
+		if _, err := os.Stat(socket); err != nil {
+			tried = append(tried, host+": not found")
+			continue
+		}
+		cli, err := connect(host, client.WithHost(host))
+		if err == nil {
+			return cli, nil
+		}
+		tried = append(tried, err.Error())
+	}
+
+	if !slices.Contains(hosts, client.DefaultDockerHost) {
+		cli, err := connect(client.DefaultDockerHost, client.FromEnv)
+		if err == nil {
+			return cli, nil
+		}
+		tried = append(tried, err.Error())
+	}
+	return nil, fmt.Errorf("No Podman or Docker endpoint found. Please start Podman or Docker or set MAXLOG_HOST. Tried:\n  %s", strings.Join(tried, "\n  "))
+}
+
+// connect creates a Moby client and checks that the endpoint answers.
+//
+// Parameters:
+//
+//	name - The endpoint or the variable it comes from, used in errors.
+//	opt  - The option selecting the endpoint.
+//
+// Returns:
+//
+//	*client.Client - The connected client.
+//	error - An error if the client cannot be created or the endpoint does not answer a ping.
+func connect(name string, opt client.Opt) (*client.Client, error) {
+	cli, err := client.NewClientWithOpts(opt, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if _, err := cli.Ping(ctx); err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return cli, nil
+}
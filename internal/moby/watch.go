@@ -0,0 +1,224 @@
+package moby
+
+import (
+	"context"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/source"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/api/types/filters"
+)
+
+const (
+	// leaveDelay is the time the log stream of a container that has died gets to deliver its last lines.
+	leaveDelay = time.Second
+
+	// resubscribeDelay is the wait before the events are subscribed again after the stream has broken off.
+	resubscribeDelay = 2 * time.Second
+)
+
+// containerWatch keeps track of the containers a Watch has reported as joined.
+type containerWatch struct {
+	src    *Source                 // The source selecting the containers.
+	ctx    context.Context         // The context of the watch.
+	events chan source.TargetEvent // The channel receiving the events.
+	names  map[string]bool         // The names of the containers to reattach to when they start again.
+	joined map[string]string       // The ID of every joined container, by container name.
+	last   int64                   // The time of the last container event handled, in nanoseconds.
+}
+
+// Watch reports the selected containers joining when they start and leaving when they die.
+//
+// Parameters:
+//
+//	ctx - The context. The channel is closed when it is cancelled.
+//
+// Returns:
+//
+//	<-chan source.TargetEvent - The channel receiving the events.
+//	error - An error if the container name is not set or ambiguous, or the containers cannot be listed.
+//
+// Behavior:
+//   - Subscribes to the start and die events of the containers before listing them, so no restart is missed.
+//   - The running containers join with the tail. A plain name that matches no container yet is waited for.
+//   - A container with a watched name that starts again, e.g. with a new ID after it has been recreated,
+//     joins from its start time. The announcement shows the new ID and the start time.
+//   - Subscribes again from the last event if the events stream breaks off, e.g. when the engine restarts.
+func (src *Source) Watch(ctx context.Context) (<-chan source.TargetEvent, error) {
+	if src.name == "" && len(src.labels) == 0 {
+		_, err := src.GetContainers(ctx)
+		return nil, err
+	}
+
+	options := events.ListOptions{Filters: filters.NewArgs(
+		filters.Arg("type", string(events.ContainerEventType)),
+		filters.Arg("event", string(events.ActionStart)),
+		filters.Arg("event", string(events.ActionDie)),
+	)}
+	for _, label := range src.labels {
+		options.Filters.Add("label", label)
+	}
+	subscribed := time.Now().UnixNano()
+	messages, errs := src.cli.Events(ctx, options)
+
+	selected, candidates, err := src.candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 && len(candidates) > 0 {
+		_, err := src.GetContainers(ctx)
+		return nil, err
+	}
+
+	cw := &containerWatch{
+		src:    src,
+		ctx:    ctx,
+		events: make(chan source.TargetEvent),
+		names:  map[string]bool{},
+		joined: map[string]string{},
+		last:   subscribed,
+	}
+	for _, p := range src.patterns {
+		if p.regex == nil && !p.glob {
+			cw.names[p.text] = true
+		}
+	}
+
+	go func() {
+		defer close(cw.events)
+		for _, summary := range selected {
+			cw.names[containerName(summary)] = true
+			cw.join(summary.ID, containerName(summary), false)
+		}
+		for {
+			select {
+			case message := <-messages:
+				cw.handle(message)
+			case <-errs:
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(resubscribeDelay):
+				}
+				options.Since = timestamp(time.Unix(0, cw.last))
+				messages, errs = src.cli.Events(ctx, options)
+			}
+		}
+	}()
+	return cw.events, nil
+}
+
+// handle reports a watched container starting or dying.
+//
+// Parameters:
+//
+//	message - The container event.
+func (cw *containerWatch) handle(message events.Message) {
+	if message.TimeNano <= cw.last {
+		return
+	}
+	cw.last = message.TimeNano
+
+	name := message.Actor.Attributes["name"]
+	if !cw.watched(name) {
+		return
+	}
+	switch message.Action {
+	case events.ActionStart:
+		cw.join(message.Actor.ID, name, true)
+	case events.ActionDie:
+		if cw.joined[name] != message.Actor.ID {
+			return
+		}
+		delete(cw.joined, name)
+		select {
+		case <-cw.ctx.Done():
+			return
+		case <-time.After(leaveDelay):
+		}
+		cw.send(source.TargetEvent{
+			Type:   source.TargetLeft,
+			Target: source.Target{Name: name, ID: message.Actor.ID, Timestamped: true},
+			Note:   "exit code " + orUnknown(message.Actor.Attributes["exitCode"]),
+		})
+	}
+}
+
+// watched checks whether a started container belongs to the selection.
+//
+// Parameters:
+//
+//	name - The name of the container.
+//
+// Returns:
+//
+//	bool - true if the name has been watched from the start or matches a glob or /regex/ pattern,
+//	or only labels are set, which the events are already filtered by.
+func (cw *containerWatch) watched(name string) bool {
+	if cw.names[name] || len(cw.src.patterns) == 0 {
+		return true
+	}
+	for _, p := range cw.src.patterns {
+		if (p.regex != nil || p.glob) && p.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// join reports a container joining.
+//
+// Parameters:
+//
+//	id      - The ID of the container.
+//	name    - The name of the container.
+//	started - Whether the container has just started. Its log is then streamed from its start time instead of the tail.
+func (cw *containerWatch) join(id string, name string, started bool) {
+	cw.joined[name] = id
+	event := source.TargetEvent{
+		Type:   source.TargetJoined,
+		Target: source.Target{Name: name, ID: id, Timestamped: true},
+		Note:   "ID " + id[:min(12, len(id))],
+	}
+	if started {
+		info, err := cw.src.cli.ContainerInspect(cw.ctx, id)
+		if err == nil && info.ContainerJSONBase != nil && info.State != nil {
+			if since, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+				event.Target.Since = since
+				event.Note += ", started " + since.Local().Format(time.DateTime)
+			}
+		}
+		if event.Target.Since.IsZero() {
+			event.Target.FromStart = true
+		}
+	}
+	cw.send(event)
+}
+
+// send passes an event to the channel unless the watch has been cancelled.
+//
+// Parameters:
+//
+//	event - The event to send.
+func (cw *containerWatch) send(event source.TargetEvent) {
+	select {
+	case cw.events <- event:
+	case <-cw.ctx.Done():
+	}
+}
+
+// orUnknown replaces an empty value for an announcement.
+//
+// Parameters:
+//
+//	value - The value.
+//
+// Returns:
+//
+//	string - The value, or unknown if it is empty.
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,167 @@
+package record
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter decides which records are shown.
+type Filter struct {
+	focus   [][]term // The alternatives a record must match one of. Empty means all records are shown.
+	exclude [][]term // The alternatives hiding a record if it matches one of them.
+}
+
+// term is a single word or /regex/ of a filter expression.
+type term struct {
+	text  string         // The word in lower case, empty for a regex.
+	regex *regexp.Regexp // The compiled case-insensitive /regex/, nil for a word.
+}
+
+// NewFilter creates a Filter for the given focus and exclude expressions.
+//
+// Parameters:
+//
+//	focus   - The expression a record must match to be shown. Empty means all records are shown.
+//	exclude - The expression hiding a record if it matches, e.g. health-check hits. Empty hides nothing.
+//
+// Returns:
+//
+//	*Filter - A pointer to the initialized Filter.
+//	error - An error if a regular expression is invalid.
+//
+// Behavior:
+//   - Compiles the expressions once, so matching stays cheap on high-volume streams.
+//   - Comma-separated alternatives match if one of them matches (OR).
+//   - Terms joined by " AND " match if all of them match.
+//   - A term is a word the line must contain or a regular expression between slashes, e.g. /BMXAA\d{4}E/.
+//     Neither is case-sensitive.
+func NewFilter(focus string, exclude string) (*Filter, error) {
+	f := &Filter{}
+	var err error
+	if f.focus, err = parseExpression(focus); err != nil {
+		return nil, fmt.Errorf("Invalid focus: %w", err)
+	}
+	if f.exclude, err = parseExpression(exclude); err != nil {
+		return nil, fmt.Errorf("Invalid exclude: %w", err)
+	}
+	return f, nil
+}
+
+// Match checks whether a record passes the filter.
+//
+// Parameters:
+//
+//	rec - The record to check.
+//
+// Returns:
+//
+//	bool - true if the record should be shown, otherwise false. A nil Filter shows all records.
+//
+// Behavior:
+//   - Checks the raw line, so the timestamp, level and logger can be matched as well.
+//   - A record matching an exclude alternative is hidden even if it matches the focus.
+func (f *Filter) Match(rec Record) bool {
+	if f == nil {
+		return true
+	}
+	lower := ""
+	if len(f.focus) > 0 || len(f.exclude) > 0 {
+		lower = strings.ToLower(rec.Raw)
+	}
+	if len(f.focus) > 0 && !matchAny(f.focus, rec.Raw, lower) {
+		return false
+	}
+	return !matchAny(f.exclude, rec.Raw, lower)
+}
+
+// matchAny checks whether a line matches one of the alternatives of an expression.
+//
+// Parameters:
+//
+//	alternatives - The parsed expression.
+//	line         - The line.
+//	lower        - The line in lower case, used for the words.
+//
+// Returns:
+//
+//	bool - true if all terms of an alternative match, otherwise false.
+func matchAny(alternatives [][]term, line string, lower string) bool {
+	for _, terms := range alternatives {
+		matched := true
+		for _, t := range terms {
+			if t.regex != nil {
+				matched = t.regex.MatchString(line)
+			} else {
+				matched = strings.Contains(lower, t.text)
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExpression parses a focus or exclude expression.
+//
+// Parameters:
+//
+//	expression - The comma-separated alternatives, each with terms joined by " AND ".
+//
+// Returns:
+//
+//	[][]term - The alternatives and their terms. Empty terms and alternatives are left out.
+//	error - An error if a regular expression is invalid.
+func parseExpression(expression string) ([][]term, error) {
+	alternatives := [][]term{}
+	for _, alternative := range splitTerms(expression, ",") {
+		terms := []term{}
+		for _, text := range splitTerms(alternative, " AND ") {
+			if text = strings.TrimSpace(text); text == "" {
+				continue
+			}
+			if len(text) > 1 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/") {
+				regex, err := regexp.Compile("(?i)" + text[1:len(text)-1])
+				if err != nil {
+					return nil, fmt.Errorf("'%s': %w", text, err)
+				}
+				terms = append(terms, term{regex: regex})
+			} else {
+				terms = append(terms, term{text: strings.ToLower(text)})
+			}
+		}
+		if len(terms) > 0 {
+			alternatives = append(alternatives, terms)
+		}
+	}
+	return alternatives, nil
+}
+
+// splitTerms splits an expression at a separator outside of regular expressions.
+//
+// Parameters:
+//
+//	expression - The expression.
+//	sep        - The separator, e.g. a comma.
+//
+// Returns:
+//
+//	[]string - The parts. A part starting with a slash extends to its closing slash, so /a{1,3}/ stays whole.
+func splitTerms(expression string, sep string) []string {
+	parts := []string{}
+	open := false
+	for _, piece := range strings.Split(expression, sep) {
+		if open {
+			parts[len(parts)-1] += sep + piece
+		} else {
+			parts = append(parts, piece)
+		}
+		part := strings.TrimSpace(parts[len(parts)-1])
+		open = strings.HasPrefix(part, "/") && (len(part) == 1 || !strings.HasSuffix(part, "/"))
+	}
+	return parts
+}
@@ -0,0 +1,117 @@
+package record
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTerms(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		sep        string
+		want       []string
+	}{
+		{"words", "a,b, c", ",", []string{"a", "b", " c"}},
+		{"empty", "", ",", []string{""}},
+		{"comma inside a regex", "/a{1,3}/,b", ",", []string{"/a{1,3}/", "b"}},
+		{"several commas inside a regex", "x, /(a,b,c)/ ,y", ",", []string{"x", " /(a,b,c)/ ", "y"}},
+		{"separator inside a regex", "/a AND b/ AND c", " AND ", []string{"/a AND b/", "c"}},
+		{"single slash", "/,a", ",", []string{"/,a"}},
+		{"unclosed regex", "/a,b", ",", []string{"/a,b"}},
+		{"slashes inside a word", "a/b,c/", ",", []string{"a/b", "c/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTerms(tt.expression, tt.sep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTerms() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       [][]string
+		wantErr    bool
+	}{
+		{"empty", "", [][]string{}, false},
+		{"alternatives", "Error, BMXAA", [][]string{{"error"}, {"bmxaa"}}, false},
+		{"terms", "cron AND failed,ui", [][]string{{"cron", "failed"}, {"ui"}}, false},
+		{"regex with a comma", `/BMXAA\d{4,5}E/,x`, [][]string{{`(?i)BMXAA\d{4,5}E`}, {"x"}}, false},
+		{"empty parts", " , a AND ,", [][]string{{"a"}}, false},
+		{"lower-case and is a word", "a and b", [][]string{{"a and b"}}, false},
+		{"invalid regex", "/a(/", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alternatives, err := parseExpression(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got [][]string
+			if alternatives != nil {
+				got = [][]string{}
+			}
+			for _, terms := range alternatives {
+				texts := []string{}
+				for _, term := range terms {
+					if term.regex != nil {
+						texts = append(texts, term.regex.String())
+					} else {
+						texts = append(texts, term.text)
+					}
+				}
+				got = append(got, texts)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExpression() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	const line = "16 Oct 2025 14:05:00:123 [ERROR] [MXServer] [CID-CRON-42] BMXAA6713E - The MBO fetch operation failed."
+	tests := []struct {
+		name    string
+		focus   string
+		exclude string
+		want    bool
+	}{
+		{"no filter", "", "", true},
+		{"word", "fetch", "", true},
+		{"word in another case", "FETCH OPERATION", "", true},
+		{"missing word", "timeout", "", false},
+		{"one alternative matches", "timeout, mbo", "", true},
+		{"all terms match", "error AND cron", "", true},
+		{"one term misses", "error AND ui", "", false},
+		{"regex", `/BMXAA\d{4}E/`, "", true},
+		{"regex with a comma", `/BMXAA\d{4,5}W/, cron-42`, "", true},
+		{"regex ignores case", "/mxserver/", "", true},
+		{"excluded", "", "CID-CRON", false},
+		{"excluded despite the focus", "error", "/cron-\\d+/", false},
+		{"exclude misses", "error", "health", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.focus, tt.exclude)
+			if err != nil {
+				t.Fatalf("NewFilter() error = %v", err)
+			}
+			if got := f.Match(Parse(line)); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var nilFilter *Filter
+	if !nilFilter.Match(Parse(line)) {
+		t.Error("Match() of a nil Filter = false, want true")
+	}
+	if _, err := NewFilter("", "/a(/"); err == nil {
+		t.Error("NewFilter() with an invalid exclude regex: error = nil, want an error")
+	}
+}
@@ -0,0 +1,133 @@
+package record
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// [10/16/25 14:05:01:123 UTC] 0000004a SystemOut   O message
+	libertyBasic = regexp.MustCompile(`^\[(\d{1,2}/\d{1,2}/\d{2,4},? \d{1,2}:\d{2}:\d{2}[:.,]\d{3} \S+)\] ([0-9a-fA-F]{8}) (\S+)\s+([AIWEFDOR]) (.*)$`)
+
+	// 16 Oct 2025 14:05:01:123 [INFO] [MXServer] [CID-CRON-123] message
+	maximoLog4j = regexp.MustCompile(`^(\d{1,2} \w{3} \d{4} \d{2}:\d{2}:\d{2}[:.,]\d{3}) \[(\w+)\s*\] \[([^\]]*)\] (?:\[([^\]]*)\] )?(.*)$`)
+
+	// [AUDIT   ] CWWKF0011I: message
+	libertyConsole = regexp.MustCompile(`^\[(\w+)\s*\] (.*)$`)
+
+	// [ERROR] anywhere in the line
+	anyLevel = regexp.MustCompile(`\[(DEBUG|INFO|AUDIT|WARN|WARNING|ERROR|err|FATAL)\s*\]`)
+
+	messageCode = regexp.MustCompile(`\bBMXAA\d{4}[EIW]\b`)
+
+	millis = regexp.MustCompile(`(\d{1,2}:\d{2}:\d{2})[:,](\d{3})`)
+
+	libertyLevels = map[string]string{
+		"D": LevelDebug,
+		"I": LevelInfo,
+		"A": LevelAudit,
+		"W": LevelWarning,
+		"E": LevelError,
+		"F": LevelFatal,
+	}
+)
+
+// Parse splits a log line into a Record.
+//
+// Parameters:
+//
+//	line - The log line without the trailing newline.
+//
+// Returns:
+//
+//	Record - The parsed record. Fields that cannot be detected are left empty.
+//
+// Behavior:
+//   - Recognises the Liberty basic format of messages.log and SystemOut.log.
+//   - Recognises the Maximo log4j format, also when it is wrapped in a Liberty line.
+//   - Recognises the Liberty console format, e.g. [AUDIT   ] CWWKF0011I.
+//   - Falls back to the first level in brackets anywhere in the line.
+//   - Extracts the first BMXAA message code of the message.
+func Parse(line string) Record {
+	rec := Record{Raw: line, Message: line}
+
+	if m := libertyBasic.FindStringSubmatch(line); m != nil {
+		rec.Time = parseTime(m[1], "1/2/06 15:04:05.000 MST", "1/2/2006 15:04:05.000 MST")
+		rec.Thread = m[2]
+		rec.Logger = m[3]
+		rec.Level = libertyLevels[m[4]]
+		rec.Message = m[5]
+	}
+
+	if m := maximoLog4j.FindStringSubmatch(rec.Message); m != nil {
+		if rec.Time.IsZero() {
+			rec.Time = parseTime(m[1], "2 Jan 2006 15:04:05.000")
+		}
+		rec.Level = normaliseLevel(m[2])
+		rec.Logger = m[3]
+		if m[4] != "" {
+			rec.Thread = m[4]
+		}
+		rec.Message = m[5]
+	} else if m := libertyConsole.FindStringSubmatch(rec.Message); m != nil && rec.Level == "" && normaliseLevel(m[1]) != "" {
+		rec.Level = normaliseLevel(m[1])
+		rec.Message = m[2]
+	} else if m := anyLevel.FindStringSubmatch(rec.Message); m != nil && rec.Level == "" {
+		rec.Level = normaliseLevel(m[1])
+	}
+
+	rec.Code = messageCode.FindString(rec.Message)
+	return rec
+}
+
+// normaliseLevel maps the level spellings of Maximo and Liberty onto the Level constants.
+//
+// Parameters:
+//
+//	level - The level as written in the log line.
+//
+// Returns:
+//
+//	string - The normalised level or an empty string if the level is unknown.
+func normaliseLevel(level string) string {
+	switch strings.ToUpper(level) {
+	case "DEBUG", "TRACE":
+		return LevelDebug
+	case "INFO":
+		return LevelInfo
+	case "AUDIT":
+		return LevelAudit
+	case "WARN", "WARNING":
+		return LevelWarning
+	case "ERR", "ERROR":
+		return LevelError
+	case "FATAL":
+		return LevelFatal
+	}
+	return ""
+}
+
+// parseTime parses a timestamp using the first matching layout.
+//
+// Parameters:
+//
+//	value   - The timestamp as written in the log line.
+//	layouts - The layouts to try. Milliseconds must be separated by a dot.
+//
+// Returns:
+//
+//	time.Time - The parsed time or the zero time if no layout matches.
+//
+// Behavior:
+//   - Replaces the colon before the milliseconds with a dot, as Maximo writes 14:05:01:123.
+//   - Removes the comma newer Liberty versions write between date and time.
+func parseTime(value string, layouts ...string) time.Time {
+	value = millis.ReplaceAllString(strings.Replace(value, ", ", " ", 1), "$1.$2")
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,33 @@
+package record
+
+import (
+	"time"
+)
+
+const (
+	LevelDebug   = "DEBUG"
+	LevelInfo    = "INFO"
+	LevelAudit   = "AUDIT"
+	LevelWarning = "WARNING"
+	LevelError   = "ERROR"
+	LevelFatal   = "FATAL"
+)
+
+// StreamStderr is the Stream of a line a container has written to stderr.
+const StreamStderr = "stderr"
+
+// Record represents a single parsed log line.
+type Record struct {
+	Time      time.Time `json:"time,omitzero"`       // The timestamp of the line, either from the stream or the text.
+	Level     string    `json:"level,omitempty"`     // The normalised log level, e.g. INFO or ERROR.
+	Logger    string    `json:"logger,omitempty"`    // The logger or server name, e.g. MXServer or SystemOut.
+	Thread    string    `json:"thread,omitempty"`    // The thread or correlation ID, e.g. CID-CRON-123.
+	Code      string    `json:"code,omitempty"`      // The BMXAA message code.
+	Source    string    `json:"source,omitempty"`    // The pod or container the line was read from.
+	Container string    `json:"container,omitempty"` // The container inside the pod, if any.
+	Cluster   string    `json:"cluster,omitempty"`   // The cluster of the pod if several clusters are streamed.
+	Namespace string    `json:"namespace,omitempty"` // The namespace of the pod if several namespaces are streamed.
+	Stream    string    `json:"stream,omitempty"`    // The output stream if it is known and not stdout, i.e. stderr.
+	Message   string    `json:"message"`             // The message without the parsed prefix.
+	Raw       string    `json:"raw"`                 // The complete line without the trailing newline.
+}
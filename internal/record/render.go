@@ -0,0 +1,187 @@
+package record
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/maxtoolbox/maxlog/internal/cmdln"
+)
+
+const (
+	PrefixNone      = "none"      // No prefix.
+	PrefixPod       = "pod"       // The short pod or container name, e.g. all-x2k4q.
+	PrefixName      = "name"      // The full pod or container name.
+	PrefixContainer = "container" // The container or app type, falling back to the name.
+)
+
+// timeLayout is the format of the timestamp of a rendered record, as written by Maximo.
+const timeLayout = "2 Jan 2006 15:04:05.000"
+
+// readyMessage is the message Maximo writes once it has started. It is shown with a green label.
+const readyMessage = "Maximo is ready for client connections."
+
+// generated matches the ReplicaSet hash and random suffix of a Deployment pod name.
+var generated = regexp.MustCompile(`-[0-9a-z]{6,10}-([0-9a-z]{5})$`)
+
+// label describes the text and colors of a label.
+type label struct {
+	text    string // The text of the label.
+	color   string // The foreground color of the label's edges.
+	bgColor string // The background color of the label.
+}
+
+// levelLabels holds the labels of the levels, by level.
+var levelLabels = map[string]label{
+	LevelDebug:   {"DEBUG", cmdln.Cyan, cmdln.BGCyan},
+	LevelInfo:    {"INFO", cmdln.Blue, cmdln.BGBlue},
+	LevelAudit:   {"AUDIT", cmdln.Blue, cmdln.BGBlue},
+	LevelWarning: {"WARN", cmdln.Yellow, cmdln.BGYellow},
+	LevelError:   {"ERROR", cmdln.Red, cmdln.BGRed},
+	LevelFatal:   {"FATAL", cmdln.Red, cmdln.BGRed},
+}
+
+// loggerLabels holds the labels of the well-known Maximo loggers, by logger.
+var loggerLabels = map[string]label{
+	"MXServer":  {"MX", cmdln.Magenta, cmdln.BGMagenta},
+	"MAXIMO_UI": {"UI", cmdln.Magenta, cmdln.BGMagenta},
+	"maximo":    {"MAX", cmdln.Cyan, cmdln.BGCyan},
+}
+
+// Render formats a record for the terminal.
+//
+// Parameters:
+//
+//	rec    - The record to format.
+//	tag    - An optional tag to highlight in the text.
+//	prefix - The kind of source prefix, one of the Prefix constants.
+//
+// Returns:
+//
+//	string - The colour-coded line including the trailing newline.
+//
+// Behavior:
+//   - Formats the parsed fields using renderFields, or the raw text using SetLabels if the line has no parsed prefix,
+//     e.g. a stack trace.
+//   - Marks a line read from stderr with a subdued [stderr] in front of it. Its level is left as parsed.
+//   - Puts the label of the source in front of the line, in a color that is stable per source.
+func Render(rec Record, tag, prefix string) string {
+	text := ""
+	if rec.Message == rec.Raw {
+		text = cmdln.SetLabels(rec.Raw, tag)
+	} else {
+		text = renderFields(rec, tag)
+	}
+	if rec.Stream != "" {
+		text = cmdln.DarkGray + "[" + rec.Stream + "]" + cmdln.Reset + " " + text
+	}
+	if label := Label(rec, prefix); label != "" {
+		text = cmdln.SourceColor(label) + label + cmdln.Reset + " " + text
+	}
+	return text + "\n"
+}
+
+// renderFields formats the parsed fields of a record.
+//
+// Parameters:
+//
+//	rec - The record with a parsed prefix.
+//	tag - An optional tag to highlight. A logger named maximo.script.<tag> is shown as Script.
+//
+// Returns:
+//
+//	string - The timestamp, the level and logger labels, the thread and the message.
+//
+// Behavior:
+//   - Shows the timestamp only if the line has one in its text, i.e. a logger has been parsed.
+//   - Shows the well-known levels and loggers as color-coded labels, other loggers in brackets.
+//   - Shows the ready message of Maximo with a green label.
+//   - Downplays cron task lines and BMXAA6372I, and highlights lines containing the tag.
+func renderFields(rec Record, tag string) string {
+	head := ""
+	if rec.Logger != "" && !rec.Time.IsZero() {
+		head = rec.Time.Local().Format(timeLayout)
+	}
+
+	parts := []string{}
+	if l, found := levelLabels[rec.Level]; found {
+		parts = append(parts, cmdln.Label(l.text, l.color, l.bgColor))
+	}
+	if l, found := loggerLabels[rec.Logger]; found {
+		parts = append(parts, cmdln.Label(l.text, l.color, l.bgColor))
+	} else if tag != "" && rec.Logger == "maximo.script."+tag {
+		parts = append(parts, cmdln.Label("Script", cmdln.LightBlue, cmdln.BGLightBlue))
+	} else if rec.Logger != "" {
+		parts = append(parts, "["+rec.Logger+"]")
+	}
+	if rec.Thread != "" {
+		parts = append(parts, "["+rec.Thread+"]")
+	}
+	parts = append(parts, cmdln.SetGreenLabel(rec.Message, readyMessage, readyMessage))
+	rest := strings.Join(parts, " ")
+
+	switch {
+	case strings.Contains(rec.Raw, "CID-CRON") || rec.Code == "BMXAA6372I":
+		rest = cmdln.DarkGray + rest + cmdln.Reset
+	case tag != "" && strings.Contains(rec.Message, tag):
+		rest = cmdln.White + cmdln.SetGreenLabel(rest, tag, tag) + cmdln.Reset
+	}
+	if head == "" {
+		return rest
+	}
+	return head + " " + rest
+}
+
+// Label builds the source label of a record.
+//
+// The cluster and namespace are put in front of the label if several are streamed, even without a prefix.
+//
+// Parameters:
+//
+//	rec    - The record.
+//	prefix - The kind of source prefix, one of the Prefix constants.
+//
+// Returns:
+//
+//	string - The label or an empty string for PrefixNone and unknown kinds without a cluster.
+func Label(rec Record, prefix string) string {
+	label := ""
+	switch prefix {
+	case PrefixPod:
+		label = ShortName(rec.Source)
+	case PrefixName:
+		label = rec.Source
+	case PrefixContainer:
+		label = rec.Container
+		if label == "" {
+			label = rec.Source
+		}
+	}
+	scope := []string{}
+	for _, part := range []string{rec.Cluster, rec.Namespace, label} {
+		if part != "" {
+			scope = append(scope, part)
+		}
+	}
+	return strings.Join(scope, "/")
+}
+
+// ShortName shortens a generated pod name to its last name segment and random suffix.
+//
+// Parameters:
+//
+//	name - The pod name, e.g. inst1-masdev-all-5d7f9c9b8-x2k4q.
+//
+// Returns:
+//
+//	string - The short name, e.g. all-x2k4q, or the name itself if it is not generated.
+func ShortName(name string) string {
+	m := generated.FindStringSubmatchIndex(name)
+	if m == nil {
+		return name
+	}
+	base := name[:m[0]]
+	if i := strings.LastIndex(base, "-"); i >= 0 {
+		base = base[i+1:]
+	}
+	return base + "-" + name[m[2]:m[3]]
+}
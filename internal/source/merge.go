@@ -0,0 +1,124 @@
+package source
+
+import (
+	"container/heap"
+	"io"
+	"sync"
+	"time"
+)
+
+// merger orders the lines of several streams by their timestamps before writing them.
+type merger struct {
+	w      io.Writer     // The writer receiving the ordered lines.
+	window time.Duration // The time a line is held back to wait for older lines. 0 holds all lines until close.
+
+	mu    sync.Mutex
+	queue mergeQueue // The lines held back, ordered by timestamp.
+	seq   uint64     // The number of lines added, used to keep the arrival order of equal timestamps.
+
+	stop    chan struct{} // Closed to stop the flushing goroutine.
+	stopped chan struct{} // Closed when the flushing goroutine has stopped.
+}
+
+// mergeItem is a single line held back by a merger.
+type mergeItem struct {
+	stamp   time.Time // The timestamp of the line.
+	arrived time.Time // The time the line has been read.
+	seq     uint64    // The arrival order of the line.
+	text    string    // The rendered line.
+}
+
+// mergeQueue is a min-heap of lines ordered by timestamp and arrival.
+type mergeQueue []*mergeItem
+
+func (q mergeQueue) Len() int { return len(q) }
+
+func (q mergeQueue) Less(i, j int) bool {
+	if q[i].stamp.Equal(q[j].stamp) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].stamp.Before(q[j].stamp)
+}
+
+func (q mergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *mergeQueue) Push(x any) { *q = append(*q, x.(*mergeItem)) }
+
+func (q *mergeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// newMerger creates a merger and starts flushing lines older than the window.
+//
+// Parameters:
+//
+//	w      - The writer receiving the ordered lines.
+//	window - The time a line is held back. 0 holds all lines until close.
+//
+// Returns:
+//
+//	*merger - A pointer to the initialized merger.
+func newMerger(w io.Writer, window time.Duration) *merger {
+	m := &merger{
+		w:       w,
+		window:  window,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(m.stopped)
+		if window <= 0 {
+			<-m.stop
+			return
+		}
+		ticker := time.NewTicker(max(window/4, 10*time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case now := <-ticker.C:
+				m.flush(now.Add(-window))
+			}
+		}
+	}()
+	return m
+}
+
+// add holds back a line.
+//
+// Parameters:
+//
+//	stamp - The timestamp of the line.
+//	text  - The rendered line.
+func (m *merger) add(stamp time.Time, text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	heap.Push(&m.queue, &mergeItem{stamp: stamp, arrived: time.Now(), seq: m.seq, text: text})
+}
+
+// flush writes the oldest lines as long as they have been read before the given time.
+//
+// Parameters:
+//
+//	before - Lines read after this time are held back. The zero time writes all lines.
+func (m *merger) flush(before time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.queue.Len() > 0 && (before.IsZero() || !m.queue[0].arrived.After(before)) {
+		item := heap.Pop(&m.queue).(*mergeItem)
+		io.WriteString(m.w, item.text)
+	}
+}
+
+// close stops the merger and writes all lines still held back.
+func (m *merger) close() {
+	close(m.stop)
+	<-m.stopped
+	m.flush(time.Time{})
+}
@@ -0,0 +1,649 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/cmdln"
+	"github.com/maxtoolbox/maxlog/internal/record"
+)
+
+const (
+	// shutdownGrace is the time streams get to finish after the context has been cancelled.
+	shutdownGrace = 2 * time.Second
+
+	// maxReconnects is the number of reconnects in a row without a new line before a stream is given up.
+	maxReconnects = 10
+
+	// maxBackoff is the longest wait between two reconnects.
+	maxBackoff = 30 * time.Second
+
+	// untilGrace is the time lines up to the until option get to arrive before followed streams are ended.
+	untilGrace = 5 * time.Second
+)
+
+// errWindowEnd ends a stream that has passed the until option.
+var errWindowEnd = errors.New("End of the time window")
+
+// Stream reads the logs of all targets of a LogSource and writes the labelled lines.
+//
+// Parameters:
+//
+//	ctx  - The context. Cancelling it, e.g. on Ctrl-C, stops all streams.
+//	src  - The LogSource to read from.
+//	opts - The options containing the tag to highlight.
+//	w    - The writer receiving the formatted log lines.
+//
+// Returns:
+//
+//	error - An error if the targets cannot be listed, there is no target or the merge window is not positive when following.
+//
+// Behavior:
+//   - Rejects a merge window of 0 or less when following, as the merged lines would only be written at the end.
+//   - Ends all streams shortly after the until option if following.
+//   - Uses streamWatch if the watch and follow options are set and the source is a Watcher.
+//   - Uses the default prefix of a Prefixer for the current targets if no prefix is set.
+//   - Opens a stream for every target and starts a goroutine per stream using session.run.
+//   - Waits until all streams have finished or the context has been cancelled.
+//   - Writes a summary of which streams ended and why.
+func Stream(ctx context.Context, src LogSource, opts Options, w io.Writer) error {
+	if opts.Merge && opts.Follow && opts.MergeWindow <= 0 {
+		return fmt.Errorf("Invalid merge window '%s'. Please use a positive duration when following, e.g. 1s.", opts.MergeWindow)
+	}
+	if opts.Follow && !opts.Until.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, opts.Until.Add(untilGrace))
+		defer cancel()
+	}
+	sess := newSession(ctx, opts, w)
+
+	if watcher, ok := src.(Watcher); ok && opts.Watch && opts.Follow {
+		if prefixer, ok := src.(Prefixer); ok && sess.prefix == "" {
+			if targets, err := src.Targets(ctx); err == nil {
+				sess.prefix = prefixer.DefaultPrefix(targets)
+			}
+		}
+		if err := streamWatch(sess, src, watcher); err != nil {
+			return err
+		}
+		sess.wait()
+		sess.close()
+		return nil
+	}
+
+	targets, err := src.Targets(ctx)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("Nothing to stream. No pod, container or file matches the selection.")
+	}
+
+	if prefixer, ok := src.(Prefixer); ok && sess.prefix == "" {
+		sess.prefix = prefixer.DefaultPrefix(targets)
+	}
+	for _, target := range targets {
+		sess.start(ctx, src, target, &position{})
+	}
+	sess.wait()
+	sess.close()
+	return nil
+}
+
+// streamWatch streams the targets of a Watcher as they join and leave.
+//
+// Parameters:
+//
+//	sess    - The session running the streams.
+//	src     - The LogSource to read from.
+//	watcher - The Watcher of the source.
+//
+// Returns:
+//
+//	error - An error if the watch cannot be started.
+//
+// Behavior:
+//   - Opens a stream for every joining target and announces it.
+//   - Replaces the stream of a target that joins again, e.g. after a restart. The new stream continues
+//     at the position of the replaced one, as its reconnects may already have written the lines of the restart.
+//   - Closes the stream of a leaving target and announces it.
+//   - Returns when the watch ends, i.e. when the context is cancelled.
+func streamWatch(sess *session, src LogSource, watcher Watcher) error {
+	events, err := watcher.Watch(sess.ctx)
+	if err != nil {
+		return err
+	}
+
+	streams := map[string]*watchedStream{}
+	for event := range events {
+		key := targetKey(event.Target)
+		pos := &position{}
+		if stream, found := streams[key]; found {
+			stream.cancel()
+			delete(streams, key)
+			if event.Type == TargetJoined && event.Target.Timestamped && stream.stopped() && !stream.pos.last.IsZero() {
+				pos = stream.pos
+				pos.resume()
+				pos.marker = ""
+				event.Target.Since, event.Target.FromStart = pos.last, false
+			}
+		}
+
+		announce(sess.out, event)
+		if event.Type == TargetLeft {
+			continue
+		}
+
+		streamCtx, cancel := context.WithCancel(sess.ctx)
+		streams[key] = &watchedStream{cancel: cancel, pos: pos, done: sess.start(streamCtx, src, event.Target, pos)}
+	}
+
+	for _, stream := range streams {
+		stream.cancel()
+	}
+	return nil
+}
+
+// watchedStream is the stream of a target of a Watcher.
+type watchedStream struct {
+	cancel context.CancelFunc // Cancels the stream.
+	pos    *position          // The position of the stream, owned by the stream until it has ended.
+	done   <-chan struct{}    // Closed when the stream has ended.
+}
+
+// stopped waits for a cancelled stream to end.
+//
+// Returns:
+//
+//	bool - true if the stream has ended within shutdownGrace, so its position can be taken over, otherwise false.
+func (stream *watchedStream) stopped() bool {
+	select {
+	case <-stream.done:
+		return true
+	case <-time.After(shutdownGrace):
+		return false
+	}
+}
+
+// announce writes a line reporting a target joining or leaving.
+//
+// Parameters:
+//
+//	w     - The writer receiving the line.
+//	event - The event to report. Its note is appended in parentheses.
+func announce(w io.Writer, event TargetEvent) {
+	name := targetName(event.Target)
+	if event.Note != "" {
+		name += " (" + event.Note + ")"
+	}
+	if event.Type == TargetJoined {
+		fmt.Fprintln(w, cmdln.SetGreenLabel("[JOIN] "+name, "[JOIN]", "JOIN"))
+	} else {
+		fmt.Fprintln(w, cmdln.SetYellowLabel("[LEAVE] "+name, "[LEAVE]", "LEAVE"))
+	}
+}
+
+// session tracks the streams of a single Stream call.
+type session struct {
+	ctx    context.Context // The context of the session.
+	out    io.Writer       // The synchronised writer receiving the output.
+	filter *record.Filter  // The filter deciding which records are shown, nil shows all records.
+	tag    string          // The tag to highlight.
+	follow bool            // Whether the streams are followed and resumed after a disconnect.
+	prefix string          // The kind of source prefix of every line.
+	merger *merger         // The merger ordering the lines by timestamp, nil if not merging.
+	since  time.Time       // The time lines are shown from, zero for no limit.
+	until  time.Time       // The time lines are shown up to, zero for no limit.
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	results []*streamResult // The streams in the order they were started.
+}
+
+// streamResult records how a stream has ended.
+type streamResult struct {
+	target Target // The target of the stream.
+	done   bool   // Whether the stream has ended.
+	err    error  // The reason the stream has ended. nil means end of stream.
+}
+
+// newSession creates a session writing to w.
+//
+// Parameters:
+//
+//	ctx  - The context of the session.
+//	opts - The options containing the tag to highlight.
+//	w    - The writer receiving the formatted log lines.
+//
+// Returns:
+//
+//	*session - A pointer to the initialized session.
+//
+// Behavior:
+//   - Creates a merger if the merge option is set. Without follow, all lines are sorted at the end.
+func newSession(ctx context.Context, opts Options, w io.Writer) *session {
+	sess := &session{
+		ctx:    ctx,
+		out:    &syncWriter{w: w},
+		filter: opts.Filter,
+		tag:    opts.Tag,
+		follow: opts.Follow,
+		prefix: opts.Prefix,
+		since:  opts.Since,
+		until:  opts.Until,
+	}
+	if opts.Merge {
+		window := opts.MergeWindow
+		if !opts.Follow {
+			window = 0
+		}
+		sess.merger = newMerger(sess.out, window)
+	}
+	return sess
+}
+
+// start opens the stream of a target and processes it in a new goroutine.
+//
+// Parameters:
+//
+//	ctx    - The context of the stream.
+//	src    - The LogSource to read from.
+//	target - The target to stream.
+//	pos    - The position of the stream, empty for a new stream.
+//
+// Returns:
+//
+//	<-chan struct{} - A channel closed when the stream has ended.
+//
+// Behavior:
+//   - Records an error in the summary if the stream cannot be opened.
+//   - Runs the stream using run.
+func (sess *session) start(ctx context.Context, src LogSource, target Target, pos *position) <-chan struct{} {
+	result := &streamResult{target: target}
+	sess.mu.Lock()
+	sess.results = append(sess.results, result)
+	sess.mu.Unlock()
+
+	done := make(chan struct{})
+	reader, err := src.Open(ctx, target)
+	if err != nil {
+		sess.finish(result, fmt.Errorf("Error opening logs: %w", err))
+		close(done)
+		return done
+	}
+
+	sess.wg.Add(1)
+	go func() {
+		defer sess.wg.Done()
+		defer close(done)
+		sess.finish(result, sess.run(ctx, src, target, reader, pos))
+	}()
+	return done
+}
+
+// run processes a stream and resumes it after a disconnect.
+//
+// Parameters:
+//
+//	ctx    - The context of the stream.
+//	src    - The LogSource to read from.
+//	target - The target of the stream.
+//	reader - The opened stream.
+//	pos    - The position of the stream.
+//
+// Returns:
+//
+//	error - The reason the stream has ended.
+//
+// Behavior:
+//   - Closes the stream when the context is cancelled.
+//   - Reopens a followed stream of a timestamped target when it ends, with exponential backoff, unless the target has ended.
+//   - Resumes at the timestamp of the last line and skips the lines already written.
+//   - Writes a dim marker line for a reconnect once the reopened stream delivers a new line,
+//     so reopening a stream that has ended, e.g. of a terminated container, stays silent.
+//   - Gives up after maxReconnects reconnects in a row without a new line.
+//   - Ends without an error once the stream has passed the until option.
+func (sess *session) run(ctx context.Context, src LogSource, target Target, reader io.ReadCloser, pos *position) error {
+	attempts := 0
+	var err error
+	for {
+		if reader != nil {
+			stop := context.AfterFunc(ctx, func() { reader.Close() })
+			err = sess.writeLogs(ctx, reader, target, pos)
+			stop()
+		}
+		if errors.Is(err, errWindowEnd) || (errors.Is(ctx.Err(), context.DeadlineExceeded) && !sess.until.IsZero()) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !sess.follow || !target.Timestamped || target.Ended {
+			return err
+		}
+
+		if pos.written > 0 {
+			attempts = 0
+		}
+		attempts++
+		if attempts > maxReconnects {
+			if err == nil {
+				err = io.EOF
+			}
+			return fmt.Errorf("Gave up after %d reconnects: %w", maxReconnects, err)
+		}
+
+		backoff := min(time.Second<<(attempts-1), maxBackoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		pos.resume()
+		target.Since = pos.last
+		reader, err = src.Open(ctx, target)
+		if err != nil {
+			reader = nil
+			continue
+		}
+		marker := "reconnected to " + targetName(target)
+		if !pos.last.IsZero() {
+			marker += ", resuming at " + pos.last.Local().Format(time.RFC3339)
+		}
+		pos.marker = dimMarker(marker)
+	}
+}
+
+// dimMarker formats a marker line reporting something about a stream, e.g. a reconnect.
+//
+// Parameters:
+//
+//	text - The text of the marker.
+//
+// Returns:
+//
+//	string - The text in brackets, in dark gray.
+func dimMarker(text string) string {
+	return cmdln.DarkGray + " [" + text + "]" + cmdln.Reset
+}
+
+// position remembers where a stream is, so it can be resumed without losing or duplicating lines.
+type position struct {
+	last     time.Time // The latest timestamp written.
+	count    int       // The number of lines written with exactly that timestamp.
+	skip     int       // The number of lines at that timestamp still to be skipped after resuming.
+	resuming bool      // Whether the stream has been reopened at the latest timestamp and has not passed it yet.
+	written  int       // The number of lines written since the stream has been (re)opened.
+	marker   string    // The reconnect marker to write before the next new line, empty if none.
+}
+
+// resume prepares the position for a reopened stream starting at the latest timestamp.
+func (pos *position) resume() {
+	pos.skip = pos.count
+	pos.resuming = true
+	pos.written = 0
+}
+
+// seen checks whether a line has already been written before the stream was resumed.
+//
+// Parameters:
+//
+//	stamp - The timestamp of the line. The zero time is never skipped.
+//
+// Returns:
+//
+//	bool - true if the line must be skipped, otherwise false.
+//
+// Behavior:
+//   - Skips lines only while a resumed stream repeats what has been written, i.e. up to the latest timestamp.
+//   - Writes lines arriving out of order otherwise, e.g. Kubernetes events, which are not strictly ordered.
+func (pos *position) seen(stamp time.Time) bool {
+	switch {
+	case stamp.IsZero():
+	case stamp.Before(pos.last):
+		if pos.resuming {
+			return true
+		}
+	case stamp.Equal(pos.last):
+		if pos.resuming && pos.skip > 0 {
+			pos.skip--
+			return true
+		}
+		pos.count++
+		pos.resuming = false
+	default:
+		pos.last, pos.count, pos.skip, pos.resuming = stamp, 1, 0, false
+	}
+	pos.written++
+	return false
+}
+
+// finish marks a stream as ended.
+//
+// Parameters:
+//
+//	result - The result of the stream.
+//	err    - The reason the stream has ended.
+func (sess *session) finish(result *streamResult, err error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	result.done = true
+	result.err = err
+}
+
+// wait blocks until all streams have ended.
+//
+// Behavior:
+//   - Gives the streams shutdownGrace to end once the context has been cancelled.
+func (sess *session) wait() {
+	finished := make(chan struct{})
+	go func() {
+		sess.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-sess.ctx.Done():
+		select {
+		case <-finished:
+		case <-time.After(shutdownGrace):
+		}
+	}
+}
+
+// close writes the lines held back by the merger and the summary.
+func (sess *session) close() {
+	if sess.merger != nil {
+		sess.merger.close()
+	}
+	sess.summary()
+}
+
+// summary writes which streams have ended and why.
+//
+// Behavior:
+//   - Is silent for a single stream that reached its end without an error.
+func (sess *session) summary() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if len(sess.results) == 1 && sess.results[0].done && sess.results[0].err == nil {
+		return
+	}
+
+	fmt.Fprintln(sess.out, cmdln.DarkGray+fmt.Sprintf("--- %d streams ---", len(sess.results))+cmdln.Reset)
+	for _, result := range sess.results {
+		reason := "end of stream"
+		if !result.done || errors.Is(result.err, context.Canceled) {
+			reason = "cancelled"
+		} else if result.err != nil {
+			reason = cmdln.GetSymbol(cmdln.SymError) + " " + result.err.Error()
+		}
+		fmt.Fprintln(sess.out, cmdln.DarkGray+targetName(result.target)+": "+cmdln.Reset+reason)
+	}
+}
+
+// writeLogs reads log lines from a stream and processes them.
+//
+// Parameters:
+//
+//	ctx    - The context of the stream.
+//	reader - The stream providing the log lines.
+//	target - The target the stream belongs to.
+//	pos    - The position of the stream, used to skip lines already written.
+//
+// Returns:
+//
+//	error - nil at the end of the stream, errWindowEnd after the until option, the context's error if it has been cancelled, otherwise the read error.
+//
+// Behavior:
+//   - Continuously reads lines from the stream until EOF is reached.
+//   - Writes a line starting with NoticeMark as a dim marker, bypassing the filter and the merger.
+//   - Each other line is parsed into a record using newRecord.
+//   - Skips the lines before the since option. Lines without a timestamp count as the line before them.
+//   - Stops at the first line after the until option, as the lines of a stream are in timestamp order.
+//   - Records passing the filter are rendered with the tag and source prefix and written.
+//   - Passes the records to the merger if the session merges, using the previous timestamp for lines without one.
+func (sess *session) writeLogs(ctx context.Context, reader io.ReadCloser, target Target, pos *position) error {
+	defer reader.Close()
+
+	var stamp time.Time
+	buffer := bufio.NewReader(reader)
+	for {
+		line, err := buffer.ReadString('\n')
+		if notice, found := strings.CutPrefix(line, NoticeMark); found {
+			fmt.Fprintln(sess.out, dimMarker(strings.TrimRight(notice, "\r\n")))
+		} else if line != "" {
+			rec := newRecord(target, line)
+			if target.Timestamped && pos.seen(rec.Time) {
+				continue
+			}
+			if pos.marker != "" {
+				fmt.Fprintln(sess.out, pos.marker)
+				pos.marker = ""
+			}
+			if !rec.Time.IsZero() {
+				stamp = rec.Time
+			}
+			if !sess.until.IsZero() && stamp.After(sess.until) {
+				return errWindowEnd
+			}
+			if !stamp.IsZero() && stamp.Before(sess.since) {
+				continue
+			}
+			if sess.filter.Match(rec) {
+				text := record.Render(rec, sess.tag, sess.prefix)
+				if sess.merger != nil {
+					sess.merger.add(stamp, text)
+				} else {
+					fmt.Fprint(sess.out, text)
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// newRecord parses a line read from a target into a record.
+//
+// Parameters:
+//
+//	target - The target the line was read from.
+//	line   - The line as read from the stream.
+//
+// Returns:
+//
+//	record.Record - The parsed record with the source fields of the target.
+//
+// Behavior:
+//   - Strips a leading StderrMark and marks the record as read from stderr.
+//   - Strips the leading timestamp if the target provides one and uses it as the record's time.
+func newRecord(target Target, line string) record.Record {
+	line = strings.TrimRight(line, "\r\n")
+	line, stderr := strings.CutPrefix(line, StderrMark)
+
+	var stamp time.Time
+	if target.Timestamped {
+		prefix, rest, found := strings.Cut(line, " ")
+		if t, err := time.Parse(time.RFC3339Nano, prefix); found && err == nil {
+			stamp, line = t, rest
+		}
+	}
+
+	rec := record.Parse(line)
+	rec.Source = target.Name
+	rec.Container = target.Container
+	rec.Cluster = target.Cluster
+	rec.Namespace = target.Namespace
+	if !stamp.IsZero() {
+		rec.Time = stamp
+	}
+	if stderr {
+		rec.Stream = record.StreamStderr
+	}
+	return rec
+}
+
+// targetKey identifies a target across watch events.
+//
+// Parameters:
+//
+//	target - The target.
+//
+// Returns:
+//
+//	string - The cluster, namespace, name and container of the target.
+func targetKey(target Target) string {
+	return target.Cluster + "/" + target.Namespace + "/" + target.Name + "/" + target.Container
+}
+
+// targetName formats the name of a target for the output.
+//
+// Parameters:
+//
+//	target - The target.
+//
+// Returns:
+//
+//	string - The name of the target, preceded by the cluster and namespace and followed by the container in parentheses if set.
+func targetName(target Target) string {
+	name := target.Name
+	if target.Namespace != "" {
+		name = target.Namespace + "/" + name
+	}
+	if target.Cluster != "" {
+		name = target.Cluster + "/" + name
+	}
+	if target.Container != "" {
+		return name + " (" + target.Container + ")"
+	}
+	return name
+}
+
+// syncWriter serialises writes from concurrent streams so lines are not torn apart.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write writes p to the underlying writer while holding the lock.
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
@@ -0,0 +1,376 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/cmdln"
+	"github.com/maxtoolbox/maxlog/internal/record"
+)
+
+// ansi matches the color codes of the output.
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// fakeSource is a LogSource serving fixed streams.
+type fakeSource struct {
+	targets []Target            // The targets of the source.
+	streams map[string][]string // The content of the streams of every target, in the order they are opened, by target name.
+	errs    map[string]error    // The error opening a target, by target name.
+	cancel  context.CancelFunc  // Called at the end of the last stream of a target, e.g. to end a followed session.
+	follow  bool                // Whether the streams wait for their context to be cancelled at their end.
+
+	mu     sync.Mutex
+	opened map[string]int // The number of times every target has been opened, by target name.
+	sinces []time.Time    // The Since of the targets in the order they have been opened.
+}
+
+func (src *fakeSource) Targets(context.Context) ([]Target, error) {
+	return src.targets, nil
+}
+
+func (src *fakeSource) Open(ctx context.Context, target Target) (io.ReadCloser, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if err := src.errs[target.Name]; err != nil {
+		return nil, err
+	}
+	if src.opened == nil {
+		src.opened = map[string]int{}
+	}
+	n := src.opened[target.Name]
+	src.opened[target.Name]++
+	src.sinces = append(src.sinces, target.Since)
+	streams := src.streams[target.Name]
+	if n >= len(streams) {
+		return nil, errors.New("No more streams")
+	}
+	if n == len(streams)-1 && src.cancel != nil {
+		return &cancelOnEOF{Reader: strings.NewReader(streams[n]), cancel: src.cancel}, nil
+	}
+	if src.follow {
+		return &followReader{Reader: strings.NewReader(streams[n]), ctx: ctx}, nil
+	}
+	return io.NopCloser(strings.NewReader(streams[n])), nil
+}
+
+func (src *fakeSource) Describe(context.Context) ([]Property, error) {
+	return nil, nil
+}
+
+// cancelOnEOF is a stream calling cancel once it has been read completely.
+type cancelOnEOF struct {
+	io.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnEOF) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.cancel()
+	}
+	return n, err
+}
+
+func (r *cancelOnEOF) Close() error {
+	return nil
+}
+
+// followReader is a followed stream waiting for new lines at its end until its context is cancelled.
+type followReader struct {
+	io.Reader
+	ctx context.Context
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		<-r.ctx.Done()
+	}
+	return n, err
+}
+
+func (r *followReader) Close() error {
+	return nil
+}
+
+// fakeWatcher is a fakeSource whose targets join and leave by the events of a channel.
+type fakeWatcher struct {
+	*fakeSource
+	events chan TargetEvent
+}
+
+func (src *fakeWatcher) Watch(context.Context) (<-chan TargetEvent, error) {
+	return src.events, nil
+}
+
+// lockedBuffer is a buffer that can be read while the streams write to it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return ansi.ReplaceAllString(b.buf.String(), "")
+}
+
+// waitFor waits until the output contains a text.
+func (b *lockedBuffer) waitFor(t *testing.T, text string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(b.String(), text); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Output %q does not contain %q", b.String(), text)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	stamp := func(second int) string {
+		return time.Date(2025, 10, 16, 14, 5, second, 0, time.UTC).Format(time.RFC3339Nano)
+	}
+	at := func(second int) time.Time {
+		return time.Date(2025, 10, 16, 14, 5, second, 0, time.UTC)
+	}
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	focus, err := record.NewFilter("keep", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		targets []Target
+		streams map[string][]string
+		errs    map[string]error
+		opts    Options
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "single stream",
+			targets: []Target{{Name: "a"}},
+			streams: map[string][]string{"a": {"first\nsecond\nlast without newline"}},
+			want:    "first\nsecond\nlast without newline\n",
+		},
+		{
+			name:    "nothing to stream",
+			wantErr: "Nothing to stream. No pod, container or file matches the selection.",
+		},
+		{
+			name:    "summary",
+			targets: []Target{{Name: "a"}, {Name: "b"}},
+			streams: map[string][]string{"a": {"line\n"}},
+			errs:    map[string]error{"b": errors.New("denied")},
+			want: "line\n--- 2 streams ---\na: end of stream\nb: " +
+				cmdln.GetSymbol(cmdln.SymError) + " Error opening logs: denied\n",
+		},
+		{
+			name:    "filter and notice",
+			targets: []Target{{Name: "a"}},
+			streams: map[string][]string{"a": {"keep 1\ndrop\n" + NoticeMark + "a has been rotated\nkeep 2\n"}},
+			opts:    Options{Filter: focus},
+			want:    "keep 1\n [a has been rotated]\nkeep 2\n",
+		},
+		{
+			name:    "since and until",
+			targets: []Target{{Name: "a", Timestamped: true}},
+			streams: map[string][]string{"a": {stamp(0) + " early\n\tat early\n" + stamp(5) + " start\n\tat start\n" +
+				stamp(10) + " end\n" + stamp(15) + " late\n" + stamp(20) + " later\n"}},
+			opts: Options{Since: at(5), Until: at(10)},
+			want: "start\n\tat start\nend\n",
+		},
+		{
+			name:    "until ends a followed stream",
+			targets: []Target{{Name: "a", Timestamped: true}},
+			streams: map[string][]string{"a": {until.Add(-time.Minute).Format(time.RFC3339) + " in\n" +
+				until.Add(time.Minute).Format(time.RFC3339) + " out\n"}},
+			opts: Options{Follow: true, Until: until},
+			want: "in\n",
+		},
+		{
+			name:    "reconnect",
+			targets: []Target{{Name: "a", Timestamped: true}},
+			streams: map[string][]string{"a": {
+				stamp(0) + " one\n" + stamp(1) + " two\n" + stamp(1) + " three\n",
+				stamp(1) + " two\n" + stamp(1) + " three\n" + stamp(2) + " four\n",
+			}},
+			opts: Options{Follow: true},
+			want: "one\ntwo\nthree\n [reconnected to a, resuming at " + at(1).Local().Format(time.RFC3339) + "]\nfour\n" +
+				"--- 1 streams ---\na: cancelled\n",
+		},
+		{
+			name:    "no reconnect without timestamps",
+			targets: []Target{{Name: "a"}},
+			streams: map[string][]string{"a": {"one\n", "two\n"}},
+			opts:    Options{Follow: true},
+			want:    "one\n",
+		},
+		{
+			name:    "merge",
+			targets: []Target{{Name: "a", Timestamped: true}, {Name: "b", Timestamped: true}},
+			streams: map[string][]string{
+				"a": {stamp(0) + " a0\n" + stamp(2) + " a2\n\tat a2\n"},
+				"b": {stamp(1) + " b1\n" + stamp(3) + " b3\n"},
+			},
+			opts: Options{Merge: true, MergeWindow: time.Second},
+			want: "a0\nb1\na2\n\tat a2\nb3\n--- 2 streams ---\na: end of stream\nb: end of stream\n",
+		},
+		{
+			name:    "merge window when following",
+			targets: []Target{{Name: "a"}},
+			opts:    Options{Follow: true, Merge: true},
+			wantErr: "Invalid merge window '0s'. Please use a positive duration when following, e.g. 1s.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			src := &fakeSource{targets: tt.targets, streams: tt.streams, errs: tt.errs}
+			if tt.opts.Follow {
+				src.cancel = cancel
+			}
+
+			var out bytes.Buffer
+			err := Stream(ctx, src, tt.opts, &out)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Stream() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+			if got, want := ansi.ReplaceAllString(out.String(), ""), ansi.ReplaceAllString(tt.want, ""); got != want {
+				t.Errorf("Stream() wrote %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	stamp := time.Date(2025, 10, 16, 14, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		timestamped bool
+		line        string
+		wantTime    time.Time
+		wantStream  string
+		wantRaw     string
+	}{
+		{"timestamped", true, "2025-10-16T14:05:00Z hello\n", stamp, "", "hello"},
+		{"stderr", true, StderrMark + "2025-10-16T14:05:00Z [ERROR] failed\n", stamp, record.StreamStderr, "[ERROR] failed"},
+		{"without timestamp", true, "\tat com.ibm.Foo\n", time.Time{}, "", "\tat com.ibm.Foo"},
+		{"not timestamped", false, "2025-10-16T14:05:00Z hello\r\n", time.Time{}, "", "2025-10-16T14:05:00Z hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newRecord(Target{Name: "maximo", Timestamped: tt.timestamped}, tt.line)
+			if !rec.Time.Equal(tt.wantTime) {
+				t.Errorf("Time = %v, want %v", rec.Time, tt.wantTime)
+			}
+			if rec.Stream != tt.wantStream {
+				t.Errorf("Stream = %q, want %q", rec.Stream, tt.wantStream)
+			}
+			if rec.Raw != tt.wantRaw {
+				t.Errorf("Raw = %q, want %q", rec.Raw, tt.wantRaw)
+			}
+			if rec.Source != "maximo" {
+				t.Errorf("Source = %q, want maximo", rec.Source)
+			}
+		})
+	}
+}
+
+func TestStreamWatch(t *testing.T) {
+	stamp := func(second int) string {
+		return time.Date(2025, 10, 16, 14, 5, second, 0, time.UTC).Format(time.RFC3339Nano)
+	}
+	target := Target{Name: "a", Timestamped: true}
+	src := &fakeWatcher{
+		fakeSource: &fakeSource{
+			streams: map[string][]string{"a": {
+				stamp(0) + " one\n" + stamp(1) + " two\n",
+				stamp(1) + " two\n" + stamp(2) + " three\n",
+			}},
+			follow: true,
+		},
+		events: make(chan TargetEvent),
+	}
+
+	var out lockedBuffer
+	done := make(chan error)
+	go func() {
+		done <- Stream(context.Background(), src, Options{Follow: true, Watch: true}, &out)
+	}()
+
+	src.events <- TargetEvent{Type: TargetJoined, Target: target}
+	out.waitFor(t, "two\n")
+	restarted := target
+	restarted.FromStart = true
+	src.events <- TargetEvent{Type: TargetJoined, Target: restarted, Note: "restarted"}
+	out.waitFor(t, "three\n")
+	src.events <- TargetEvent{Type: TargetLeft, Target: target}
+	close(src.events)
+	if err := <-done; err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+
+	join := func(name string) string { return cmdln.SetGreenLabel("[JOIN] "+name, "[JOIN]", "JOIN") + "\n" }
+	want := join("a") + "one\ntwo\n" + join("a (restarted)") + "three\n" + cmdln.SetYellowLabel("[LEAVE] a", "[LEAVE]", "LEAVE") + "\n"
+	if got, _, _ := strings.Cut(out.String(), "---"); got != ansi.ReplaceAllString(want, "") {
+		t.Errorf("Stream() wrote %q, want %q", got, want)
+	}
+	if wantSinces := []time.Time{{}, time.Date(2025, 10, 16, 14, 5, 1, 0, time.UTC)}; !slices.EqualFunc(src.sinces, wantSinces, time.Time.Equal) {
+		t.Errorf("Open() since = %v, want %v", src.sinces, wantSinces)
+	}
+}
+
+func TestPositionSeen(t *testing.T) {
+	at := func(second int) time.Time {
+		return time.Date(2025, 10, 16, 14, 5, second, 0, time.UTC)
+	}
+	tests := []struct {
+		name   string
+		before []time.Time // The stamps written before the stream is resumed, nil for a stream that is not resumed.
+		stamps []time.Time
+		want   []bool
+	}{
+		{"in order", nil, []time.Time{at(1), at(2), at(2), at(3)}, []bool{false, false, false, false}},
+		{"out of order", nil, []time.Time{at(1), at(3), at(2), at(4)}, []bool{false, false, false, false}},
+		{"without timestamp", nil, []time.Time{at(2), {}, at(1)}, []bool{false, false, false}},
+		{"resumed", []time.Time{at(1), at(2), at(2)}, []time.Time{at(1), at(2), at(2), at(2), at(3)}, []bool{true, true, true, false, false}},
+		{"out of order after resuming", []time.Time{at(1), at(3)}, []time.Time{at(3), at(4), at(2)}, []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := &position{}
+			for _, stamp := range tt.before {
+				pos.seen(stamp)
+			}
+			if tt.before != nil {
+				pos.resume()
+			}
+			for i, stamp := range tt.stamps {
+				if got := pos.seen(stamp); got != tt.want[i] {
+					t.Errorf("seen(%v) of line %d = %v, want %v", stamp, i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maxtoolbox/maxlog/internal/record"
+)
+
+// Options holds the settings an action passes to a LogSource.
+type Options struct {
+	Namespace string // The comma-separated Kubernetes namespaces or glob patterns. Falls back to MAXLOG_K8S_NAMESPACE.
+	AppType   string // The Kubernetes app types. Falls back to MAXLOG_K8S_APPTYPE.
+	Tail      string // The number of lines to tail from each stream.
+	Follow    bool   // Whether to follow the log streams.
+	Tag       string // The tag to highlight in the log lines.
+	File      string // The log files for the file mode. Falls back to MAXLOG_FILE.
+	Watch     bool   // Whether to attach to targets joining while following.
+	Previous  bool   // Whether to read the logs of the previous container instance.
+	Container string // The comma-separated container names or patterns inside the pods. Falls back to MAXLOG_K8S_CONTAINER.
+	InitCont  bool   // Whether to include the init containers of the pods.
+	Profile   string // The name of the Kubernetes selector profile. Falls back to MAXLOG_K8S_PROFILE.
+	Instance  string // The MAS instance ID filled into the profile. Falls back to MAXLOG_MAS_INSTANCE.
+	Selector  string // The raw Kubernetes label selector, replacing the app types. Falls back to MAXLOG_K8S_SELECTOR.
+	Pod       string // The glob pattern or /regex/ the pod names must match. Falls back to MAXLOG_K8S_POD.
+
+	FieldSelector string // The Kubernetes field selector, e.g. spec.nodeName=worker1. Falls back to MAXLOG_K8S_FIELD_SELECTOR.
+	Context       string // The comma-separated kubeconfig contexts. Falls back to MAXLOG_K8S_CONTEXT.
+	Kubeconfig    string // The path of the kubeconfig file. Falls back to MAXLOG_KUBECONFIG.
+	Events        bool   // Whether to interleave the Kubernetes events of the selected pods.
+	Label         string // The comma-separated label filters of the Podman containers. Falls back to MAXLOG_CONTAINER_LABEL.
+	Prefix        string // The kind of source prefix of every line, one of the record.Prefix constants. Empty leaves it to the source.
+
+	Merge       bool          // Whether to order the lines of all streams by timestamp.
+	MergeWindow time.Duration // The time a line is held back to wait for older lines of other streams.
+
+	Since time.Time // If set, only lines from this time on are shown and the tail is ignored.
+	Until time.Time // If set, only lines up to this time are shown and the tail is ignored. Followed streams end at this time.
+
+	Filter *record.Filter // The compiled focus and exclude expressions deciding which lines are shown. nil shows all lines.
+}
+
+// Target describes a single log stream offered by a LogSource, e.g. a pod or a container.
+type Target struct {
+	Name        string    // The name of the pod or container.
+	ID          string    // The container ID, if the backend has one.
+	Container   string    // The container inside the pod, if the backend has one.
+	Cluster     string    // The cluster of the pod if several clusters are streamed, otherwise empty.
+	Namespace   string    // The namespace of the pod if several namespaces are streamed, otherwise empty.
+	Timestamped bool      // True if every line starts with an RFC3339 timestamp followed by a space. Such streams are resumed after a disconnect.
+	FromStart   bool      // True if the stream starts at the beginning instead of the tail, e.g. for a new pod.
+	Ended       bool      // True if the container has already terminated, e.g. an init container. Such streams are not resumed.
+	Since       time.Time // If set, the stream starts at this time instead of the tail, e.g. when resuming.
+}
+
+// StderrMark is put in front of a line a LogSource has read from stderr.
+// The pipeline removes it and marks the record's Stream instead, so the line is parsed as written.
+const StderrMark = "\x00stderr\x00"
+
+// NoticeMark is put in front of a line a LogSource reports about the stream instead of the log, e.g. a rotated file.
+// The pipeline writes the rest of the line as a dim marker, like a reconnect, without parsing or filtering it.
+const NoticeMark = "\x00notice\x00"
+
+// EventType describes how the targets of a Watcher have changed.
+type EventType int
+
+const (
+	TargetJoined EventType = iota // The target has become available.
+	TargetLeft                    // The target has terminated.
+)
+
+// TargetEvent reports a change of the targets of a Watcher.
+type TargetEvent struct {
+	Type   EventType
+	Target Target
+	Note   string // Additional information shown in the announcement, e.g. the new container ID.
+}
+
+// Property is a single key/value pair shown by the inspect action.
+type Property struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Details describes every target of a source for the inspect action.
+type Details struct {
+	Columns []string   // The column headers of the table.
+	Rows    [][]string // One row per target, in the order of the columns.
+	Items   any        // The details for the JSON output, e.g. a slice of structs.
+}
+
+// LogSource defines an interface for backends that provide log streams.
+type LogSource interface {
+	// Targets lists the log streams the source would read from.
+	// Returns:
+	//   []Target - The targets matching the source's options.
+	//   error - An error if the targets cannot be listed.
+	Targets(context.Context) ([]Target, error)
+
+	// Open opens the log stream of a single target.
+	// Parameters:
+	//   target - The target returned by Targets.
+	// Returns:
+	//   io.ReadCloser - A reader providing newline-terminated log lines.
+	//   error - An error if the stream cannot be opened.
+	Open(context.Context, Target) (io.ReadCloser, error)
+
+	// Describe retrieves information about the source for the inspect action.
+	// Returns:
+	//   []Property - The properties in display order.
+	//   error - An error if the information cannot be retrieved.
+	Describe(context.Context) ([]Property, error)
+}
+
+// Watcher is implemented by a LogSource whose targets can come and go while following.
+type Watcher interface {
+	// Watch reports targets joining and leaving, starting with the current targets.
+	// Parameters:
+	//   ctx - The context. The channel is closed when it is cancelled.
+	// Returns:
+	//   <-chan TargetEvent - The channel receiving the events.
+	//   error - An error if watching cannot be started.
+	Watch(context.Context) (<-chan TargetEvent, error)
+}
+
+// Detailer is implemented by a LogSource that can describe each of its targets in detail.
+type Detailer interface {
+	// Details retrieves a table of the targets for the inspect action.
+	// Parameters:
+	//   ctx - The context for the requests.
+	// Returns:
+	//   Details - The table and the items for the JSON output.
+	//   error - An error if the information cannot be retrieved.
+	Details(context.Context) (Details, error)
+}
+
+// Prefixer is implemented by a LogSource that prefixes the lines with their source if no prefix is set.
+type Prefixer interface {
+	// DefaultPrefix determines the prefix used if neither the prefix option nor MAXLOG_PREFIX is set.
+	// Parameters:
+	//   targets - The targets to stream.
+	// Returns:
+	//   string - One of the record.Prefix constants, or an empty string for no prefix.
+	DefaultPrefix([]Target) string
+}
+
+// Factory defines a function type that creates a LogSource from the given options.
+type Factory func(Options) (LogSource, error)
+
+var registry = map[string]Factory{}
+
+// Register makes a LogSource available under the given name.
+//
+// Parameters:
+//
+//	name    - The name used to select the source, e.g. the value of MAXLOG_MODE.
+//	factory - The function creating the source.
+//
+// Behavior:
+//   - Panics if a source with the same name has already been registered.
+func Register(name string, factory Factory) {
+	if _, found := registry[name]; found {
+		panic("source already registered: " + name)
+	}
+	registry[name] = factory
+}
+
+// Names returns the sorted names of all registered sources.
+//
+// Returns:
+//
+//	[]string - The names of the registered sources.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the LogSource registered under the given name.
+//
+// Parameters:
+//
+//	name - The name of the source.
+//	opts - The options passed to the source's factory.
+//
+// Returns:
+//
+//	LogSource - The created source.
+//	error - An error if no source is registered under the name or the factory fails.
+func New(name string, opts Options) (LogSource, error) {
+	factory, found := registry[name]
+	if !found {
+		return nil, fmt.Errorf("Unknown mode: '%s'. Please set MAXLOG_MODE to one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return factory(opts)
+}
@@ -21,8 +21,16 @@ import (
 	"strings"
 
 	"github.com/maxtoolbox/maxlog/internal/actions"
+
+	// Log sources register themselves under their MAXLOG_MODE name.
+	_ "github.com/maxtoolbox/maxlog/internal/k8s"
+	_ "github.com/maxtoolbox/maxlog/internal/logfile"
+	_ "github.com/maxtoolbox/maxlog/internal/moby"
 )
 
+// logsShortcuts are the parameters that may follow maxlog directly, without the logs command.
+var logsShortcuts = []string{"tag=", "focus=", "exclude=", "file=", "since=", "until="}
+
 func validateArgs(args []string) error {
 	if len(args) < 2 {
 		args = append(args, "logs")
@@ -30,10 +38,16 @@ func validateArgs(args []string) error {
 
 	command := args[1]
 	offset := 2
-	if strings.HasPrefix(command, "tag=") || strings.HasPrefix(command, "focus=") {
+	if command == "-" {
 		command = "logs"
 		offset = 1
 	}
+	for _, prefix := range logsShortcuts {
+		if strings.HasPrefix(command, prefix) {
+			command = "logs"
+			offset = 1
+		}
+	}
 
 	cmds := []actions.ActionRunner{
 		actions.ActionLogs(),